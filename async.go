@@ -0,0 +1,111 @@
+package clog
+
+import (
+	"io"
+)
+
+// --- [ asynchronous output ] -------------------------------------------------
+
+// asyncLine is a log line pending output in asynchronous mode.
+type asyncLine struct {
+	// Output writer of the log line.
+	w io.Writer
+	// Formatted log line.
+	line []byte
+	// flushed is closed once all prior log lines have been output; used by
+	// Flush (w and line are unset).
+	flushed chan struct{}
+}
+
+var (
+	// asyncLines is the queue of log lines pending output in asynchronous mode;
+	// or nil if asynchronous mode is disabled.
+	asyncLines chan asyncLine
+	// asyncStopped is closed once the goroutine of asynchronous mode has output
+	// all pending log lines and stopped.
+	asyncStopped chan struct{}
+	// asyncBufferSize specifies the capacity of the queue of log lines pending
+	// output in asynchronous mode.
+	asyncBufferSize = 1024
+)
+
+// SetAsync sets whether to output log lines asynchronously (default false).
+//
+// In asynchronous mode, formatted log lines are queued and written to the
+// output writers by a background goroutine. When the queue is full, log calls
+// block until there is room in the queue; log lines are never dropped.
+//
+// Disabling asynchronous mode is equivalent to calling Close.
+func SetAsync(async bool) {
+	if !async {
+		Close()
+		return
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if asyncLines != nil {
+		// asynchronous mode already enabled.
+		return
+	}
+	lines := make(chan asyncLine, asyncBufferSize)
+	stopped := make(chan struct{})
+	asyncLines, asyncStopped = lines, stopped
+	go func() {
+		defer close(stopped)
+		for l := range lines {
+			if l.flushed != nil {
+				close(l.flushed)
+				continue
+			}
+			if _, err := l.w.Write(l.line); err != nil {
+				// report the error from the next log call, as the write error
+				// handler may call log functions, which would block on the queue
+				// drained by this goroutine.
+				recordWriteError(err)
+			}
+		}
+	}()
+}
+
+// SetAsyncBufferSize sets the capacity of the queue of log lines pending output
+// in asynchronous mode (default 1024). The capacity takes effect the next time
+// asynchronous mode is enabled. With a capacity of 0, each log call blocks until
+// the background goroutine receives its log line.
+func SetAsyncBufferSize(n int) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	asyncBufferSize = max(n, 0)
+}
+
+// Flush blocks until all log lines queued in asynchronous mode have been
+// output, and reports pending write errors to the write error handler (see
+// SetWriteErrorHandler). Flush is a no-op if asynchronous mode is disabled.
+func Flush() {
+	outputMutex.Lock()
+	lines := asyncLines
+	if lines == nil {
+		outputMutex.Unlock()
+		return
+	}
+	flushed := make(chan struct{})
+	lines <- asyncLine{flushed: flushed}
+	outputMutex.Unlock()
+	<-flushed
+	reportWriteErrors()
+}
+
+// Close outputs all log lines queued in asynchronous mode and stops the
+// background goroutine, thus disabling asynchronous mode. Close is a no-op if
+// asynchronous mode is disabled.
+func Close() {
+	outputMutex.Lock()
+	lines, stopped := asyncLines, asyncStopped
+	asyncLines, asyncStopped = nil, nil
+	outputMutex.Unlock()
+	if lines == nil {
+		return
+	}
+	close(lines)
+	<-stopped
+	reportWriteErrors()
+}
@@ -0,0 +1,80 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncBufferSize(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 1024} {
+		buf := &bytes.Buffer{}
+		restoreOutput(t, buf)
+		SetAsyncBufferSize(n)
+		SetAsync(true)
+		Info("foo")
+		Info("bar")
+		Close()
+		if got, want := buf.String(), "clog: foo\nclog: bar\n"; got != want {
+			t.Errorf("buffer size %d: expected %q, got %q", n, want, got)
+		}
+	}
+	SetAsyncBufferSize(1024)
+}
+
+// errWriter is an output writer which fails all writes.
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (n int, err error) {
+	return 0, errors.New("write failed")
+}
+
+func TestAsyncWriteErrorHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetErrorOutput(errWriter{})
+	SetAsyncBufferSize(0)
+	defer SetAsyncBufferSize(1024)
+	// the write error handler logs, which must not block on the queue of log
+	// lines drained by the background goroutine of asynchronous mode.
+	SetWriteErrorHandler(func(err error) {
+		Info("handled: ", err)
+	})
+	defer SetWriteErrorHandler(nil)
+	SetAsync(true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Error("foo")
+		Info("bar")
+		Flush()
+		Info("baz")
+		Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock; write error handler called log function in asynchronous mode")
+	}
+	if got := strings.Count(buf.String(), "clog: handled: write failed\n"); got != 1 {
+		t.Errorf("expected one handled write error, got %q", buf.String())
+	}
+}
+
+// restoreOutput sets the output writer of all log levels to w, and restores
+// the previous output writers once the test completes.
+func restoreOutput(t testing.TB, w io.Writer) {
+	t.Helper()
+	outputMutex.Lock()
+	prevDebug, prevInfo, prevWarn, prevError := debugOutputs, infoOutputs, warnOutputs, errorOutputs
+	outputMutex.Unlock()
+	SetOutput(w)
+	t.Cleanup(func() {
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
+		debugOutputs, infoOutputs, warnOutputs, errorOutputs = prevDebug, prevInfo, prevWarn, prevError
+	})
+}
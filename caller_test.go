@@ -0,0 +1,217 @@
+package clog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mewpkg/clog"
+)
+
+func TestPrefix(t *testing.T) {
+	golden := []struct {
+		// Log level name.
+		name string
+		// Log function.
+		fn func()
+		// Line number of log call.
+		line int
+		// Whether to include file:line in the prefix of debug and info messages.
+		fileLine bool
+		// Expected output, formatted with the file name and line number of the
+		// log call.
+		want string
+	}{
+		{name: "debug", fn: func() { clog.Debug("foo") }, line: line(), want: "clog_test: foo\n"},
+		{name: "info", fn: func() { clog.Info("foo") }, line: line(), want: "clog_test: foo\n"},
+		{name: "warn", fn: func() { clog.Warn("foo") }, line: line(), want: "clog_test: %s:%d: foo\n"},
+		{name: "error", fn: func() { clog.Error("foo") }, line: line(), want: "clog_test: %s:%d: foo\n"},
+		{name: "debug", fn: func() { clog.Debug("foo") }, line: line(), fileLine: true, want: "clog_test: %s:%d: foo\n"},
+		{name: "info", fn: func() { clog.Info("foo") }, line: line(), fileLine: true, want: "clog_test: %s:%d: foo\n"},
+		{name: "warn", fn: func() { clog.Warn("foo") }, line: line(), fileLine: true, want: "clog_test: %s:%d: foo\n"},
+		{name: "error", fn: func() { clog.Error("foo") }, line: line(), fileLine: true, want: "clog_test: %s:%d: foo\n"},
+	}
+	file := thisFile()
+	for _, g := range golden {
+		clog.SetDebugFileLine(g.fileLine)
+		clog.SetInfoFileLine(g.fileLine)
+		got := clog.CaptureOutput(g.fn)
+		want := g.want
+		if want != "clog_test: foo\n" {
+			want = fmt.Sprintf(g.want, file, g.line)
+		}
+		if got != want {
+			t.Errorf("%s (file:line %v): expected %q, got %q", g.name, g.fileLine, want, got)
+		}
+	}
+	clog.SetDebugFileLine(false)
+	clog.SetInfoFileLine(false)
+}
+
+func TestPrefixColor(t *testing.T) {
+	golden := []struct {
+		name string
+		fn   func()
+		line int
+		want string
+	}{
+		{name: "debug", fn: func() { clog.Debug("foo") }, line: line(), want: "\x1b[35;1mclog_test:\x1b[0m foo\n"},
+		{name: "info", fn: func() { clog.Info("foo") }, line: line(), want: "\x1b[36;1mclog_test:\x1b[0m foo\n"},
+		{name: "warn", fn: func() { clog.Warn("foo") }, line: line(), want: "\x1b[31;1mclog_test:\x1b[0m \x1b[37;1m%s:%d:\x1b[0m foo\n"},
+		{name: "error", fn: func() { clog.Error("foo") }, line: line(), want: "\x1b[31;1mclog_test:\x1b[0m \x1b[37;1m%s:%d:\x1b[0m foo\n"},
+	}
+	clog.SetColor(true)
+	defer clog.SetForceColor(false)
+	file := thisFile()
+	for _, g := range golden {
+		got := clog.CaptureOutput(g.fn)
+		want := g.want
+		if g.name == "warn" || g.name == "error" {
+			want = fmt.Sprintf(g.want, file, g.line)
+		}
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", g.name, want, got)
+		}
+	}
+}
+
+func TestCaller(t *testing.T) {
+	ctx := context.Background()
+	err := errors.New("foo")
+	entry := clog.WithFields(clog.Fields{"k": "v"})
+	golden := []struct {
+		// Log function name.
+		name string
+		// Log function.
+		fn func()
+		// Line number of log call.
+		line int
+		// Caller skip offset (see SetCallerSkip).
+		skip int
+	}{
+		{name: "Debug", fn: func() { clog.Debug("foo") }, line: line()},
+		{name: "Debugf", fn: func() { clog.Debugf("%s", "foo") }, line: line()},
+		{name: "Debugln", fn: func() { clog.Debugln("foo") }, line: line()},
+		{name: "Debugw", fn: func() { clog.Debugw("foo", "k", "v") }, line: line()},
+		{name: "DebugString", fn: func() { clog.DebugString("foo") }, line: line()},
+		{name: "DebugBlock", fn: func() { clog.DebugBlock([]string{"foo"}, false) }, line: line()},
+		{name: "DebugLazy", fn: func() { clog.DebugLazy(func() string { return "foo" }) }, line: line()},
+		{name: "DebugCtx", fn: func() { clog.DebugCtx(ctx, "foo") }, line: line()},
+		{name: "Info", fn: func() { clog.Info("foo") }, line: line()},
+		{name: "Infof", fn: func() { clog.Infof("%s", "foo") }, line: line()},
+		{name: "Infoln", fn: func() { clog.Infoln("foo") }, line: line()},
+		{name: "Infow", fn: func() { clog.Infow("foo", "k", "v") }, line: line()},
+		{name: "InfoString", fn: func() { clog.InfoString("foo") }, line: line()},
+		{name: "InfoBlock", fn: func() { clog.InfoBlock([]string{"foo"}, false) }, line: line()},
+		{name: "InfoCtx", fn: func() { clog.InfoCtx(ctx, "foo") }, line: line()},
+		{name: "Print", fn: func() { clog.Print("foo") }, line: line()},
+		{name: "Printf", fn: func() { clog.Printf("%s", "foo") }, line: line()},
+		{name: "Println", fn: func() { clog.Println("foo") }, line: line()},
+		{name: "Warn", fn: func() { clog.Warn("foo") }, line: line()},
+		{name: "Warnf", fn: func() { clog.Warnf("%s", "foo") }, line: line()},
+		{name: "Warnln", fn: func() { clog.Warnln("foo") }, line: line()},
+		{name: "Warnw", fn: func() { clog.Warnw("foo", "k", "v") }, line: line()},
+		{name: "WarnString", fn: func() { clog.WarnString("foo") }, line: line()},
+		{name: "WarnBlock", fn: func() { clog.WarnBlock([]string{"foo"}, false) }, line: line()},
+		{name: "WarnCtx", fn: func() { clog.WarnCtx(ctx, "foo") }, line: line()},
+		{name: "WarnIf", fn: func() { clog.WarnIf(err, "foo") }, line: line()},
+		{name: "Error", fn: func() { clog.Error("foo") }, line: line()},
+		{name: "Errorf", fn: func() { clog.Errorf("%s", "foo") }, line: line()},
+		{name: "Errorln", fn: func() { clog.Errorln("foo") }, line: line()},
+		{name: "Errorw", fn: func() { clog.Errorw("foo", "k", "v") }, line: line()},
+		{name: "ErrorString", fn: func() { clog.ErrorString("foo") }, line: line()},
+		{name: "ErrorBlock", fn: func() { clog.ErrorBlock([]string{"foo"}, false) }, line: line()},
+		{name: "ErrorCtx", fn: func() { clog.ErrorCtx(ctx, "foo") }, line: line()},
+		{name: "Fatal", fn: func() { clog.Fatal("foo") }, line: line()},
+		{name: "Fatalf", fn: func() { clog.Fatalf("%s", "foo") }, line: line()},
+		{name: "Fatalln", fn: func() { clog.Fatalln("foo") }, line: line()},
+		{name: "Fatalw", fn: func() { clog.Fatalw("foo", "k", "v") }, line: line()},
+		{name: "FatalCtx", fn: func() { clog.FatalCtx(ctx, "foo") }, line: line()},
+		{name: "FatalCode", fn: func() { clog.FatalCode(2, "foo") }, line: line()},
+		{name: "FatalIf", fn: func() { clog.FatalIf(err, "foo") }, line: line()},
+		{name: "Must", fn: func() { clog.Must(0, err) }, line: line()},
+		{name: "Panic", fn: func() { defer func() { recover() }(); clog.Panic("foo") }, line: line()},
+		{name: "Panicf", fn: func() { defer func() { recover() }(); clog.Panicf("%s", "foo") }, line: line()},
+		{name: "Panicln", fn: func() { defer func() { recover() }(); clog.Panicln("foo") }, line: line()},
+		{name: "Log", fn: func() { clog.Log(clog.LevelInfo, "foo") }, line: line()},
+		{name: "Logf", fn: func() { clog.Logf(clog.LevelInfo, "%s", "foo") }, line: line()},
+		{name: "LogLevel", fn: func() { clog.LogLevel(clog.LevelInfo, "foo") }, line: line()},
+		{name: "Entry.Debug", fn: func() { entry.Debug("foo") }, line: line()},
+		{name: "Entry.Infof", fn: func() { entry.Infof("%s", "foo") }, line: line()},
+		{name: "Entry.Warnln", fn: func() { entry.Warnln("foo") }, line: line()},
+		{name: "Entry.Error", fn: func() { entry.Error("foo") }, line: line()},
+		{name: "Entry.Fatal", fn: func() { entry.Fatal("foo") }, line: line()},
+		{name: "Entry.WithPrefix", fn: func() { entry.WithPrefix("bar").Info("foo") }, line: line()},
+		{name: "Since", fn: func() { clog.Since(time.Now(), "foo") }, line: line()},
+		{name: "Timer", fn: func() { clog.Timer("foo")() }, line: line()},
+		{name: "Writer", fn: func() { clog.Writer(clog.LevelInfo).Write([]byte("foo\n")) }, line: line()},
+		{name: "slog", fn: func() { slog.New(clog.NewSlogHandler()).Info("foo") }, line: line()},
+		// log calls through wrappers.
+		{name: "Info (wrapper)", fn: func() { infoWrapper("foo") }, line: line(), skip: 1},
+		{name: "Warnf (wrapper)", fn: func() { warnfWrapper("%s", "foo") }, line: line(), skip: 1},
+		{name: "Entry.Error (wrapper)", fn: func() { entryErrorWrapper(entry, "foo") }, line: line(), skip: 1},
+		{name: "Must (wrapper)", fn: func() { mustWrapper(err) }, line: line(), skip: 1},
+		{name: "Timer (wrapper)", fn: func() { timerWrapper("foo")() }, line: line(), skip: 1},
+	}
+	clog.SetDebugFileLine(true)
+	defer clog.SetDebugFileLine(false)
+	clog.SetInfoFileLine(true)
+	defer clog.SetInfoFileLine(false)
+	clog.SetFatalBehavior(clog.FatalReturn)
+	defer clog.SetFatalBehavior(clog.FatalExit)
+	defer clog.SetCallerSkip(0)
+	file := thisFile()
+	for _, g := range golden {
+		clog.SetCallerSkip(g.skip)
+		got := clog.CaptureOutput(g.fn)
+		want := fmt.Sprintf("clog_test: %s:%d: ", file, g.line)
+		if !strings.HasPrefix(got, want) {
+			t.Errorf("%s: expected output with prefix %q, got %q", g.name, want, got)
+		}
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// infoWrapper outputs the given info message using Info.
+func infoWrapper(args ...any) {
+	clog.Info(args...)
+}
+
+// warnfWrapper outputs the given warning message using Warnf.
+func warnfWrapper(format string, args ...any) {
+	clog.Warnf(format, args...)
+}
+
+// entryErrorWrapper outputs the given error message using the Error method of
+// the given log entry.
+func entryErrorWrapper(e *clog.Entry, args ...any) {
+	e.Error(args...)
+}
+
+// mustWrapper outputs the given error using Must.
+func mustWrapper(err error) {
+	clog.Must(0, err)
+}
+
+// timerWrapper returns a timer of the given label using Timer.
+func timerWrapper(label string) func() {
+	return clog.Timer(label)
+}
+
+// line returns the line number of the caller.
+func line() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// thisFile returns the file name of the caller.
+func thisFile() string {
+	_, file, _, _ := runtime.Caller(1)
+	return file
+}
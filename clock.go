@@ -0,0 +1,39 @@
+package clog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// --- [ clock ] ---------------------------------------------------------------
+
+// clock specifies the function used to read the current time.
+var clock atomic.Pointer[func() time.Time]
+
+// SetClock sets the function used to read the current time (default time.Now);
+// e.g. for timestamps, elapsed times and deduplication windows. A nil function
+// restores the default clock.
+//
+// Tests may inject a fixed clock to make timestamps of log output
+// deterministic.
+//
+// Example usage:
+//
+//	clog.SetClock(func() time.Time {
+//		return time.Date(2024, 10, 26, 12, 0, 0, 0, time.UTC)
+//	})
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		clock.Store(nil)
+		return
+	}
+	clock.Store(&fn)
+}
+
+// now returns the current time, as read by the clock.
+func now() time.Time {
+	if fn := clock.Load(); fn != nil {
+		return (*fn)()
+	}
+	return time.Now()
+}
@@ -2,12 +2,24 @@
 package clog
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mewpkg/term"
 )
@@ -26,412 +38,2139 @@ const (
 	LevelInfo Level = 0
 	// LevelWarn is used for non-fatal warnings (Red).
 	LevelWarn Level = 4
-	// LevelError is used for fatal errors (Red).
+	// LevelError is used for errors (Red).
 	LevelError Level = 8
+	// LevelFatal is used with Log and Logf for fatal errors (Red), which
+	// terminate the application after output. Fatal errors are output as error
+	// messages.
+	LevelFatal Level = 12
 )
 
+// String returns the name of the log level (e.g. "info"). Log levels in
+// between the common log levels are named relative to the closest common log
+// level below (e.g. "info+2"), unless registered as custom log levels (see
+// RegisterLevel).
+func (l Level) String() string {
+	if name, ok := customLevelName(l); ok {
+		return name
+	}
+	str := func(name string, offset Level) string {
+		if offset == 0 {
+			return name
+		}
+		return fmt.Sprintf("%s%+d", name, offset)
+	}
+	switch {
+	case l < LevelInfo:
+		return str("debug", l-LevelDebug)
+	case l < LevelWarn:
+		return str("info", l-LevelInfo)
+	case l < LevelError:
+		return str("warn", l-LevelWarn)
+	case l < LevelFatal:
+		return str("error", l-LevelError)
+	default:
+		return str("fatal", l-LevelFatal)
+	}
+}
+
+// ParseLevel returns the log level with the given name (e.g. "info"), as
+// returned by Level.String. Names are case-insensitive and may include an
+// offset relative to a common or custom log level (e.g. "info+2").
+func ParseLevel(name string) (Level, error) {
+	base, offset := name, ""
+	if pos := strings.IndexAny(name, "+-"); pos != -1 {
+		base, offset = name[:pos], name[pos:]
+	}
+	var level Level
+	if custom, ok := customLevelValue(base); ok {
+		level = custom
+	} else {
+		var err error
+		if level, err = commonLevel(base); err != nil {
+			return 0, fmt.Errorf("invalid log level %q; %w", name, err)
+		}
+	}
+	if len(offset) > 0 {
+		n, err := strconv.Atoi(offset)
+		if err != nil {
+			return 0, fmt.Errorf("invalid log level %q; invalid offset %q", name, offset)
+		}
+		level += Level(n)
+	}
+	return level, nil
+}
+
+// commonLevel returns the common log level with the given case-insensitive
+// name.
+func commonLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "fatal":
+		return LevelFatal, nil
+	default:
+		return 0, fmt.Errorf("unknown level name %q", name)
+	}
+}
+
+// MarshalText returns the name of the log level, as returned by String.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText sets the log level to the log level with the given name, as
+// accepted by ParseLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// isKnownLevel reports whether the given log level is a common log level or a
+// custom log level.
+func isKnownLevel(level Level) bool {
+	switch level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal:
+		return true
+	default:
+		_, ok := customLevelName(level)
+		return ok
+	}
+}
+
 var (
-	// mu is a mutex for concurrent access to activeLevel.
-	mu sync.Mutex
+	// mu is a readers/writer mutex for concurrent access to activeLevel,
+	// globLevels and globalLevel; log calls only take the read lock.
+	mu sync.RWMutex
 	// activeLevel specifies the active log level at package and function
 	// granularity.
 	activeLevel = make(map[string]Level)
+	// globLevels specifies the active log level of glob patterns, ordered by
+	// decreasing specificity.
+	globLevels []globLevel
+	// globalLevel specifies the global log level, used for callers without a
+	// path level.
+	globalLevel = LevelDebug
 )
 
+// minEnabledLevel specifies the lowest log level enabled for any caller; i.e.
+// the minimum of the global log level and all path levels. Log calls below the
+// minimum enabled log level return early, without resolving their caller.
+var minEnabledLevel atomic.Int64
+
+func init() {
+	minEnabledLevel.Store(int64(globalLevel))
+}
+
+// SetLevel sets the global log level (default LevelDebug), which is used for
+// callers whose package path and function path have no log level set (see
+// SetPathLevel). Log messages below the global log level are suppressed.
+func SetLevel(level Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	globalLevel = level
+	updateMinEnabledLevel()
+}
+
+// GetLevel returns the global log level.
+func GetLevel() Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	return globalLevel
+}
+
+// globLevel is the log level of a glob pattern.
+type globLevel struct {
+	// Glob pattern (as accepted by path.Match).
+	pattern string
+	// Log level of paths matching the pattern.
+	level Level
+}
+
 // SetPathLevel sets the log level of the given path at package
 // (e.g. "github.com/user/repo/pkg") or function
 // (e.g. "github.com/user/repo/pkg.Func") granularity.
 //
-// For function ganularity of leaf node functions, function inlining may have to
-// be disabled (use the `//go:noinline` build tag).
+// The path may be a glob pattern, as accepted by path.Match (e.g.
+// "github.com/user/repo/internal/*"). Wildcards do not match slashes; e.g.
+// "internal/*" matches "internal/db" but not "internal/db/sub", which instead
+// inherits the log level of its parent directory "internal/db". When resolving
+// the log level of a path, the following precedence applies:
+//
+//  1. log level set for the exact path.
+//  2. log level set for the most specific glob pattern matching the path; where
+//     specificity is the number of non-wildcard characters of the pattern.
+//
+// When resolving the log level of a caller, exact matches of its function path
+// take precedence over exact matches of its package path, which take precedence
+// over glob matches of either, which take precedence over parent directories of
+// the package path.
 func SetPathLevel(path string, level Level) {
 	mu.Lock()
 	defer mu.Unlock()
 	activeLevel[path] = level
+	if isGlob(path) {
+		globLevels = slices.DeleteFunc(globLevels, func(g globLevel) bool {
+			return g.pattern == path
+		})
+		globLevels = append(globLevels, globLevel{pattern: path, level: level})
+		slices.SortStableFunc(globLevels, func(a, b globLevel) int {
+			return cmp.Compare(globSpecificity(b.pattern), globSpecificity(a.pattern))
+		})
+	}
+	updateMinEnabledLevel()
+}
+
+// RemovePathLevel removes the log level of the given path, and reports whether
+// a log level was set for the path.
+func RemovePathLevel(path string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := activeLevel[path]; !ok {
+		return false
+	}
+	delete(activeLevel, path)
+	globLevels = slices.DeleteFunc(globLevels, func(g globLevel) bool {
+		return g.pattern == path
+	})
+	updateMinEnabledLevel()
+	return true
+}
+
+// WithPathLevel sets the log level of the given path, and returns a function
+// which restores the previous log level of the path; or removes the log level
+// of the path if none was set before.
+//
+// Example usage:
+//
+//	defer clog.WithPathLevel("github.com/user/repo/pkg", clog.LevelDebug)()
+func WithPathLevel(path string, level Level) func() {
+	mu.RLock()
+	prev, ok := activeLevel[path]
+	mu.RUnlock()
+	SetPathLevel(path, level)
+	return func() {
+		if ok {
+			SetPathLevel(path, prev)
+		} else {
+			RemovePathLevel(path)
+		}
+	}
+}
+
+// ResetPathLevels removes the log levels of all paths.
+func ResetPathLevels() {
+	mu.Lock()
+	defer mu.Unlock()
+	activeLevel = make(map[string]Level)
+	globLevels = nil
+	updateMinEnabledLevel()
+}
+
+// ListPathLevels returns a copy of the log levels of all paths (including glob
+// patterns) which have a log level set.
+func ListPathLevels() map[string]Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	return maps.Clone(activeLevel)
 }
 
 // PathLevel returns the current log level of the given path at package or
 // function granularity, and a boolean indicating whether the log level was
 // set.
+//
+// Package paths inherit the log level of their closest parent directory (e.g.
+// "github.com/user/repo/pkg/sub" inherits the log level set for
+// "github.com/user/repo/pkg") if no log level was set for the package path
+// itself.
 func PathLevel(path string) (Level, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	level, _, ok := resolveLevel(path)
+	return level, ok
+}
+
+// ResolvePathLevel returns the current log level of the given path at package
+// or function granularity, the configured path (or glob pattern) from which the
+// log level was resolved, and a boolean indicating whether the log level was
+// set.
+//
+// The matched path differs from the given path if the log level is inherited
+// from a parent directory or set by a glob pattern (see PathLevel).
+//
+// Example usage:
+//
+//	level, matched, ok := clog.ResolvePathLevel("github.com/user/app/db")
+//	if ok && matched != "github.com/user/app/db" {
+//		fmt.Printf("%v (inherited from %s)\n", level, matched)
+//	}
+func ResolvePathLevel(path string) (level Level, matchedPath string, ok bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return resolveLevel(path)
+}
+
+// resolveLevel returns the log level of the given path, or of its closest
+// parent directory, the path or glob pattern from which the log level was
+// resolved, and a boolean indicating whether a log level was found.
+//
+// Note, mu must be held (for reading) by the caller.
+func resolveLevel(path string) (Level, string, bool) {
+	for {
+		if level, matchedPath, ok := lookupLevel(path); ok {
+			return level, matchedPath, true
+		}
+		parent := parentDir(path)
+		if parent == path {
+			return 0, "", false
+		}
+		path = parent
+	}
+}
+
+// lookupLevel returns the log level set for the given exact path or for the
+// most specific glob pattern matching the path, the path or glob pattern which
+// matched, and a boolean indicating whether a log level was found.
+//
+// Note, mu must be held (for reading) by the caller.
+func lookupLevel(path string) (Level, string, bool) {
+	if level, ok := activeLevel[path]; ok {
+		return level, path, true
+	}
+	for _, g := range globLevels {
+		if matchGlob(g.pattern, path) {
+			return g.level, g.pattern, true
+		}
+	}
+	return 0, "", false
+}
+
+// callerLevel returns the log level of the caller with the given package path
+// and function path; or the global log level if no path level is set for the
+// caller.
+//
+// Exact matches of the function path and package path take precedence over
+// glob matches, which take precedence over parent directories of the package
+// path.
+func callerLevel(pkgPath, funcPath string) Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if level, ok := activeLevel[funcPath]; ok {
+		return level
+	}
+	if level, ok := activeLevel[pkgPath]; ok {
+		return level
+	}
+	for _, g := range globLevels {
+		if matchGlob(g.pattern, funcPath) || matchGlob(g.pattern, pkgPath) {
+			return g.level
+		}
+	}
+	if parent := parentDir(pkgPath); parent != pkgPath {
+		if level, _, ok := resolveLevel(parent); ok {
+			return level
+		}
+	}
+	return globalLevel
+}
+
+// updateMinEnabledLevel updates the minimum enabled log level based on the
+// global log level and path levels.
+//
+// Note, mu must be held by the caller.
+func updateMinEnabledLevel() {
+	minLevel := globalLevel
+	for _, level := range activeLevel {
+		minLevel = min(minLevel, level)
+	}
+	minEnabledLevel.Store(int64(minLevel))
+}
+
+// disabled reports whether log output of the given log level is disabled for
+// all callers. It is cheap to call, and used to return early from log calls
+// before resolving the caller.
+func disabled(level Level) bool {
+	if countOnly.Load() {
+		// log calls of all log levels are counted in count-only mode.
+		return false
+	}
+	if m := disabledLevels.Load(); m != nil && (*m)[level] {
+		return true
+	}
+	return Level(minEnabledLevel.Load()) > level
+}
+
+// disabledLevels specifies the log levels which are disabled independently of
+// the global log level and path levels; or nil if none. The map is replaced
+// rather than modified, so it may be read without locking; updates are
+// serialized by mu.
+var disabledLevels atomic.Pointer[map[Level]bool]
+
+// SetLevelEnabled sets whether log output of the given log level is enabled
+// (default true), independently of the ordering of log levels; e.g. to disable
+// warning messages while keeping debug and error messages.
+//
+// A disabled log level takes precedence over the global log level and path
+// levels; log messages of the log level are suppressed for all callers.
+func SetLevelEnabled(level Level, enabled bool) {
 	mu.Lock()
 	defer mu.Unlock()
-	level, ok := activeLevel[path]
-	return level, ok
+	m := make(map[Level]bool)
+	if old := disabledLevels.Load(); old != nil {
+		maps.Copy(m, *old)
+	}
+	if enabled {
+		delete(m, level)
+	} else {
+		m[level] = true
+	}
+	if len(m) == 0 {
+		disabledLevels.Store(nil)
+		return
+	}
+	disabledLevels.Store(&m)
 }
 
 // skip reports whether to skip log output of the given log level for the
-// package path and function path of the caller.
-func skip(cur Level) bool {
-	pkgPath, funcPath := getQualifiedPaths()
-	if funcLevel, ok := PathLevel(funcPath); ok {
-		return funcLevel > cur
+// package path and function path of the given caller. The global log level is
+// used if no path level is set for the caller. Output of callers outside of the
+// package allowlist (see SetOnlyPackages) or of disabled log levels (see
+// SetLevelEnabled) is always skipped, as is all output in count-only mode (see
+// SetCountOnly).
+func skip(c caller, cur Level) bool {
+	pkgPath := getPkgPath(c.name)
+	if countOnly.Load() {
+		countCall(pkgPath, cur)
+		return true
+	}
+	if filtered(pkgPath) {
+		return true
+	}
+	if m := disabledLevels.Load(); m != nil && (*m)[cur] {
+		return true
+	}
+	level := callerLevel(pkgPath, c.name)
+	return level > cur
+}
+
+// skipFatal reports whether to skip the fatal log call of the given caller and
+// log level. In count-only mode, fatal log calls are counted but not skipped,
+// so the fatal behavior still applies; their output is suppressed by
+// writeFatal.
+func skipFatal(c caller, cur Level) bool {
+	return skip(c, cur) && !countOnly.Load()
+}
+
+// Enabled reports whether log output of the given log level is enabled for the
+// package path and function path of the caller. It may be used to guard the
+// construction of expensive log messages.
+//
+// Example usage:
+//
+//	if clog.Enabled(clog.LevelDebug) {
+//		clog.Debugf("state: %v", expensiveDump())
+//	}
+func Enabled(level Level) bool {
+	if disabled(level) || countOnly.Load() {
+		return false
+	}
+	c := getCaller()
+	return !skip(c, level)
+}
+
+// EffectiveLevel returns the log level in effect for the caller; i.e. the path
+// level resolved for its package path and function path, or the global log
+// level if no path level is set for the caller.
+//
+// Example usage:
+//
+//	if clog.EffectiveLevel() <= clog.LevelDebug {
+//		computeDiagnostics()
+//	}
+func EffectiveLevel() Level {
+	c := getCaller()
+	return callerLevel(getPkgPath(c.name), c.name)
+}
+
+// --- [ debug ] ---------------------------------------------------------------
+
+// outputMutex is a mutex for concurrent writes to output writers.
+var outputMutex sync.Mutex
+
+// forceColor specifies whether to use color regardless of whether the output
+// writers are terminals.
+var forceColor bool
+
+// SetForceColor sets whether to use color regardless of whether the output
+// writers are terminals (e.g. when piping output into a program which
+// understands ANSI color codes).
+func SetForceColor(force bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	forceColor = force
+}
+
+// disableColor specifies whether to disable color regardless of whether the
+// output writers are terminals.
+var disableColor bool
+
+// SetColor overrides the detection of color support; color is used for all
+// output writers if enabled, and for no output writers otherwise. SetColor is
+// the manual override when color support is misdetected (e.g. on legacy
+// Windows consoles).
+func SetColor(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	forceColor = enable
+	disableColor = !enable
+}
+
+// autoNewline specifies whether to terminate log messages with a newline.
+var autoNewline = true
+
+// SetAutoNewline sets whether to terminate log messages with a newline (default
+// true). Disabling the trailing newline lets the caller control line endings;
+// e.g. to rewrite a progress line in place using "\r". Log messages of
+// formatters (e.g. JSONFormatter) are always terminated with a newline.
+func SetAutoNewline(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	autoNewline = enable
+}
+
+// maxMessageLength specifies the maximum length in bytes of log messages; or 0
+// if unlimited.
+var maxMessageLength atomic.Int64
+
+// SetMaxMessageLength sets the maximum length in bytes of log messages (default
+// 0, which is unlimited). Longer log messages are truncated, not splitting any
+// multibyte UTF-8 characters, and marked with the number of truncated bytes
+// (e.g. "aGVsbG8…(truncated, 1024 bytes)"). Prefixes and structured fields are
+// not truncated.
+func SetMaxMessageLength(n int) {
+	maxMessageLength.Store(int64(max(n, 0)))
+}
+
+// quiet specifies whether to output log messages without prefixes and color.
+var quiet bool
+
+// SetQuiet sets whether to output raw log messages without prefixes and color
+// for all log levels (default false). The prefix settings of log levels (e.g.
+// SetDebugPrefix) are retained, and take effect again when quiet mode is
+// disabled.
+func SetQuiet(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	quiet = enable
+}
+
+var (
+	// burstGap specifies the idle time after which an empty line is output
+	// before the next log message; or 0 if disabled.
+	burstGap time.Duration
+	// lastOutputTime specifies the time of the last output log message.
+	lastOutputTime time.Time
+)
+
+// SetBurstSeparator sets the idle time after which an empty line is output
+// before the next log message (default 0, which is disabled). This visually
+// separates bursts of log messages in long-running applications. Empty lines
+// are not output for formatters (e.g. JSONFormatter).
+//
+// Example usage:
+//
+//	clog.SetBurstSeparator(2 * time.Second)
+func SetBurstSeparator(gap time.Duration) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	burstGap = max(gap, 0)
+	lastOutputTime = time.Time{}
+}
+
+// SetOutput sets the output writer of debug, info, warning and error messages.
+// Color is enabled if the output writer is a terminal. The formatter is
+// re-evaluated if automatic formatter selection is enabled (see SetAutoFormat).
+func SetOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	sinks := []sink{newSink(w)}
+	debugOutputs = sinks
+	infoOutputs = sinks
+	warnOutputs = sinks
+	errorOutputs = sinks
+	selectFormatter()
+}
+
+// UseStdoutStderrSplit sets the output writer of debug and info messages to
+// standard output, and the output writer of warning and error messages to
+// standard error. Color is enabled for each output writer which is a terminal.
+func UseStdoutStderrSplit() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	stdout, stderr := []sink{newSink(os.Stdout)}, []sink{newSink(os.Stderr)}
+	debugOutputs = stdout
+	infoOutputs = stdout
+	warnOutputs = stderr
+	errorOutputs = stderr
+	selectFormatter()
+}
+
+// sink is an output writer of log messages.
+type sink struct {
+	// Output writer.
+	w io.Writer
+	// useColor specifies whether to use color for the output writer.
+	useColor bool
+}
+
+// newSink returns a new sink for the given output writer. Color is enabled if
+// the output writer is a terminal.
+func newSink(w io.Writer) sink {
+	return sink{w: w, useColor: isTerminal(w)}
+}
+
+// SetOutputColor sets whether to use color for the given output writer, which
+// overrides the terminal detection done when the output writer was set or
+// added. The setting applies to all log levels the output writer is currently
+// registered for.
+//
+// Log lines are formatted with color for output writers using color, and
+// without color for all other output writers, so output may be sent both to a
+// terminal and to a log file.
+//
+// Example usage:
+//
+//	clog.AddDebugOutput(logFile)
+//	clog.SetOutputColor(logFile, false)
+func SetOutputColor(w io.Writer, useColor bool) {
+	if w == nil || !reflect.TypeOf(w).Comparable() {
+		return
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	for _, sinks := range [][]sink{debugOutputs, infoOutputs, warnOutputs, errorOutputs} {
+		for i := range sinks {
+			if sinks[i].w == w {
+				sinks[i].useColor = useColor
+			}
+		}
+	}
+}
+
+// SetLevelsOutput sets the output writer of messages of the given log levels,
+// which must be known log levels (e.g. LevelDebug). Color is enabled if the
+// output writer is a terminal.
+//
+// Example usage:
+//
+//	clog.SetLevelsOutput(os.Stdout, clog.LevelDebug, clog.LevelInfo)
+//	clog.SetLevelsOutput(os.Stderr, clog.LevelWarn, clog.LevelError)
+func SetLevelsOutput(w io.Writer, levels ...Level) error {
+	for _, level := range levels {
+		if !isKnownLevel(level) {
+			return fmt.Errorf("unknown log level %v", level)
+		}
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	for _, level := range levels {
+		setLevelOutput(level, w)
+	}
+	return nil
+}
+
+// Silence discards the output of messages of the given log level.
+func Silence(level Level) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	setLevelOutput(level, io.Discard)
+}
+
+// SilenceAll discards the output of messages of all log levels.
+func SilenceAll() {
+	SetOutput(io.Discard)
+}
+
+var (
+	// debugOutputs specifies the output writers of debug messages.
+	debugOutputs = []sink{newSink(os.Stderr)}
+
+	// debugUsePrefix specifies whether to use a prefix for debug messages.
+	debugUsePrefix = true
+
+	// debugUseFileLine specifies whether to include file:line in the prefix of
+	// debug messages.
+	debugUseFileLine bool
+)
+
+// SetDebugOutput sets the output writer of debug messages. Color is enabled if
+// the output writer is a terminal.
+func SetDebugOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	debugOutputs = []sink{newSink(w)}
+}
+
+// AddDebugOutput adds an output writer of debug messages. Each message is
+// written to all output writers, in the order they were added; a write error of
+// one output writer does not prevent writing to the others. Color is enabled
+// for the output writer if it is a terminal.
+func AddDebugOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	debugOutputs = append(slices.Clip(debugOutputs), newSink(w))
+}
+
+// DebugOutput returns the output writer of debug messages. If more than one
+// output writer has been added, the returned writer writes to all of them.
+func DebugOutput() io.Writer {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return sinksWriter(debugOutputs)
+}
+
+// SetDebugPrefix sets whether to use a prefix for debug messages.
+func SetDebugPrefix(usePrefix bool) {
+	debugUsePrefix = usePrefix
+}
+
+// SetDebugFileLine sets whether to include the file name and line number of the
+// caller in the prefix of debug messages (default false), as is done for warning
+// and error messages.
+func SetDebugFileLine(useFileLine bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	debugUseFileLine = useFileLine
+}
+
+// Debug outputs the given debug message to standard error.
+func Debug(args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fmt.Sprint(args...), nil)
+}
+
+// Debugf outputs the given debug message to standard error.
+func Debugf(format string, args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+// Debugln outputs the given debug message to standard error.
+func Debugln(args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, sprintln(args...), nil)
+}
+
+// DebugString outputs the given debug message to standard error. It is
+// equivalent to Debug(s) for a single string argument, but avoids formatting
+// the message using package fmt.
+func DebugString(s string) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, s, nil)
+}
+
+// DebugBlock outputs the given lines as debug messages to standard error, as a
+// single block which is not interleaved with log messages of concurrent
+// callers. The prefix is included on every line if prefixAll is set, and on the
+// first line only otherwise.
+func DebugBlock(lines []string, prefixAll bool) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	writeBlock(c, LevelDebug, lines, prefixAll)
+}
+
+// DebugLazy outputs the debug message returned by fn to standard error. fn is
+// only invoked if debug output is enabled for the caller, so that expensive
+// debug messages are only computed when needed.
+//
+// Example usage:
+//
+//	clog.DebugLazy(func() string {
+//		return expensiveDump()
+//	})
+func DebugLazy(fn func() string) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fn(), nil)
+}
+
+// --- [ info ] ----------------------------------------------------------------
+
+var (
+	// infoOutputs specifies the output writers of info messages.
+	infoOutputs = []sink{newSink(os.Stderr)}
+
+	// infoUsePrefix specifies whether to use a prefix for info messages.
+	infoUsePrefix = true
+
+	// infoUseFileLine specifies whether to include file:line in the prefix of
+	// info messages.
+	infoUseFileLine bool
+)
+
+// SetInfoOutput sets the output writer of info messages. Color is enabled if
+// the output writer is a terminal.
+func SetInfoOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	infoOutputs = []sink{newSink(w)}
+}
+
+// AddInfoOutput adds an output writer of info messages. Each message is written
+// to all output writers, in the order they were added; a write error of one
+// output writer does not prevent writing to the others. Color is enabled for
+// the output writer if it is a terminal.
+func AddInfoOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	infoOutputs = append(slices.Clip(infoOutputs), newSink(w))
+}
+
+// InfoOutput returns the output writer of info messages. If more than one
+// output writer has been added, the returned writer writes to all of them.
+func InfoOutput() io.Writer {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return sinksWriter(infoOutputs)
+}
+
+// SetInfoPrefix sets whether to use a prefix for info messages.
+func SetInfoPrefix(usePrefix bool) {
+	infoUsePrefix = usePrefix
+}
+
+// SetInfoFileLine sets whether to include the file name and line number of the
+// caller in the prefix of info messages (default false), as is done for warning
+// and error messages.
+func SetInfoFileLine(useFileLine bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	infoUseFileLine = useFileLine
+}
+
+// Info outputs the given info message to standard error.
+func Info(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprint(args...), nil)
+}
+
+// Infof outputs the given info message to standard error.
+func Infof(format string, args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+// Infoln outputs the given info message to standard error.
+func Infoln(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, sprintln(args...), nil)
+}
+
+// InfoString outputs the given info message to standard error. It is
+// equivalent to Info(s) for a single string argument, but avoids formatting the
+// message using package fmt.
+func InfoString(s string) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, s, nil)
+}
+
+// InfoBlock outputs the given lines as info messages to standard error, as a
+// single block which is not interleaved with log messages of concurrent
+// callers. The prefix is included on every line if prefixAll is set, and on the
+// first line only otherwise.
+//
+// Example usage:
+//
+//	clog.InfoBlock(strings.Split(table, "\n"), false)
+func InfoBlock(lines []string, prefixAll bool) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	writeBlock(c, LevelInfo, lines, prefixAll)
+}
+
+// Print outputs the given info message to standard error. Print is equivalent
+// to Info, and eases migration from the standard log package.
+func Print(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprint(args...), nil)
+}
+
+// Printf outputs the given info message to standard error. Printf is
+// equivalent to Infof, and eases migration from the standard log package.
+func Printf(format string, args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+// Println outputs the given info message to standard error. Println is
+// equivalent to Infoln, and eases migration from the standard log package.
+func Println(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, sprintln(args...), nil)
+}
+
+// --- [ warning ] -------------------------------------------------------------
+
+var (
+	// warnOutputs specifies the output writers of non-fatal warning messages.
+	warnOutputs = []sink{newSink(os.Stderr)}
+
+	// warnUsePrefix specifies whether to use a prefix for warning messages.
+	warnUsePrefix = true
+)
+
+// SetWarnOutput sets the output writer of non-fatal warning messages. Color is
+// enabled if the output writer is a terminal.
+func SetWarnOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	warnOutputs = []sink{newSink(w)}
+}
+
+// AddWarnOutput adds an output writer of non-fatal warning messages. Each
+// message is written to all output writers, in the order they were added; a
+// write error of one output writer does not prevent writing to the others.
+// Color is enabled for the output writer if it is a terminal.
+func AddWarnOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	warnOutputs = append(slices.Clip(warnOutputs), newSink(w))
+}
+
+// WarnOutput returns the output writer of warning messages. If more than one
+// output writer has been added, the returned writer writes to all of them.
+func WarnOutput() io.Writer {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return sinksWriter(warnOutputs)
+}
+
+// SetWarnPrefix sets whether to use a prefix for warning messages.
+func SetWarnPrefix(usePrefix bool) {
+	warnUsePrefix = usePrefix
+}
+
+// Warn outputs the given non-fatal warning message to standard error.
+func Warn(args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, fmt.Sprint(args...), nil)
+}
+
+// Warnf outputs the given non-fatal warning message to standard error.
+func Warnf(format string, args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, fmt.Sprintf(format, args...), nil)
+}
+
+// Warnln outputs the given non-fatal warning message to standard error.
+func Warnln(args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, sprintln(args...), nil)
+}
+
+// WarnString outputs the given non-fatal warning message to standard error. It
+// is equivalent to Warn(s) for a single string argument, but avoids formatting
+// the message using package fmt.
+func WarnString(s string) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, s, nil)
+}
+
+// WarnBlock outputs the given lines as non-fatal warning messages to standard
+// error, as a single block which is not interleaved with log messages of
+// concurrent callers. The prefix is included on every line if prefixAll is set,
+// and on the first line only otherwise.
+func WarnBlock(lines []string, prefixAll bool) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	writeBlock(c, LevelWarn, lines, prefixAll)
+}
+
+// WarnIf outputs the given non-fatal warning message followed by err to
+// standard error, if err is non-nil.
+//
+// Example usage:
+//
+//	clog.WarnIf(f.Close(), "unable to close file")
+func WarnIf(err error, args ...any) {
+	if err == nil {
+		return
+	}
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, errMsg(err, args...), nil)
+}
+
+// --- [ error ] ---------------------------------------------------------------
+
+var (
+	// errorOutputs specifies the output writers of error messages.
+	errorOutputs = []sink{newSink(os.Stderr)}
+
+	// errorUsePrefix specifies whether to use a prefix for error messages.
+	errorUsePrefix = true
+)
+
+// SetErrorOutput sets the output writer of error messages. Color is enabled if
+// the output writer is a terminal.
+func SetErrorOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	errorOutputs = []sink{newSink(w)}
+}
+
+// AddErrorOutput adds an output writer of error messages. Each message is
+// written to all output writers, in the order they were added; a write error of
+// one output writer does not prevent writing to the others. Color is enabled
+// for the output writer if it is a terminal.
+func AddErrorOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	errorOutputs = append(slices.Clip(errorOutputs), newSink(w))
+}
+
+// ErrorOutput returns the output writer of error messages. If more than one
+// output writer has been added, the returned writer writes to all of them.
+func ErrorOutput() io.Writer {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return sinksWriter(errorOutputs)
+}
+
+// SetErrorPrefix sets whether to use a prefix for error messages.
+func SetErrorPrefix(usePrefix bool) {
+	errorUsePrefix = usePrefix
+}
+
+// exitFunc is the function used by Fatal, Fatalf and Fatalln to terminate the
+// application.
+var exitFunc = os.Exit
+
+// SetExitFunc sets the function used by Fatal, Fatalf and Fatalln to terminate
+// the application (default os.Exit). The exit function is invoked with the
+// exit code (see SetErrorExitCode) after the fatal error message has been
+// written, and may be replaced (e.g. in tests) to record the exit code without
+// terminating the process.
+func SetExitFunc(fn func(code int)) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	exitFunc = fn
+}
+
+// errorExitCode specifies the exit code used by Fatal, Fatalf and Fatalln to
+// terminate the application.
+var errorExitCode = 1
+
+// SetErrorExitCode sets the exit code used by Fatal, Fatalf and Fatalln (and
+// other fatal log functions) to terminate the application (default 1). Use
+// FatalCode to override the exit code of a single call.
+func SetErrorExitCode(code int) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	errorExitCode = code
+}
+
+var (
+	// fatalStackTrace specifies whether to output a stack trace after fatal
+	// error messages.
+	fatalStackTrace bool
+	// fatalStackTraceAll specifies whether to include all goroutines in stack
+	// traces of fatal error messages.
+	fatalStackTraceAll bool
+)
+
+// SetFatalStackTrace sets whether to output a stack trace of the current
+// goroutine after fatal error messages, before terminating the application
+// (default false). The stack trace is written uncolored to the output writer of
+// error messages, directly beneath the fatal error message.
+func SetFatalStackTrace(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	fatalStackTrace = enable
+}
+
+// SetFatalStackTraceAll sets whether stack traces of fatal error messages
+// include all goroutines rather than only the current goroutine (default
+// false).
+func SetFatalStackTraceAll(all bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	fatalStackTraceAll = all
+}
+
+// FatalBehavior specifies the behavior of fatal log functions (e.g. Fatal)
+// after the fatal error message has been output.
+type FatalBehavior uint8
+
+// Fatal behaviors.
+const (
+	// FatalExit terminates the application using the exit function (default).
+	FatalExit FatalBehavior = iota
+	// FatalPanic panics with the fatal error message.
+	FatalPanic
+	// FatalReturn returns to the caller, as done by Error.
+	FatalReturn
+)
+
+// fatalBehavior specifies the behavior of fatal log functions.
+var fatalBehavior = FatalExit
+
+// SetFatalBehavior sets the behavior of fatal log functions (e.g. Fatal, Fatalf
+// and Fatalln) after the fatal error message has been output (default
+// FatalExit).
+//
+// Libraries should not terminate the host application; an application may set
+// FatalReturn or FatalPanic to keep running (e.g. to clean up, or to recover in
+// a request handler) when a dependency calls Fatal. Note, code calling Fatal
+// typically assumes that it does not return; with FatalReturn, execution
+// continues after the call to Fatal, which may lead to use of invalid state
+// (e.g. a nil value returned alongside an error). FatalPanic is safer in that
+// respect, as it unwinds the stack of the caller.
+func SetFatalBehavior(behavior FatalBehavior) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	fatalBehavior = behavior
+}
+
+// exit terminates the application with the error exit code, using the exit
+// function; or panics with the given fatal error message or returns, as
+// specified by the fatal behavior.
+func exit(msg string) {
+	outputMutex.Lock()
+	code := errorExitCode
+	outputMutex.Unlock()
+	exitCode(code, msg)
+}
+
+// exitCode terminates the application with the given exit code, using the exit
+// function; or panics with the given fatal error message or returns, as
+// specified by the fatal behavior. Pending log lines of asynchronous mode and
+// buffered output writers are flushed before exiting.
+func exitCode(code int, msg string) {
+	outputMutex.Lock()
+	behavior := fatalBehavior
+	outputMutex.Unlock()
+	if behavior == FatalReturn {
+		return
+	}
+	Flush()
+	flushOutputs()
+	if behavior == FatalPanic {
+		panic(msg)
+	}
+	outputMutex.Lock()
+	fn := exitFunc
+	outputMutex.Unlock()
+	fn(code)
+}
+
+// FatalIf outputs the given fatal error message followed by err to standard
+// error and terminates the application, if err is non-nil.
+//
+// Example usage:
+//
+//	clog.FatalIf(err, "unable to open config file")
+func FatalIf(err error, args ...any) {
+	if err == nil {
+		return
+	}
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := errMsg(err, args...)
+	writeFatal(c, LevelError, msg, nil)
+	exit(msg)
+}
+
+// Must returns v if err is nil; otherwise, it outputs err as a fatal error
+// message to standard error and terminates the application.
+//
+// Example usage:
+//
+//	f := clog.Must(os.Open(path))
+func Must[T any](v T, err error) T {
+	if err == nil {
+		return v
+	}
+	if disabled(LevelError) {
+		return v
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return v
+	}
+	msg := err.Error()
+	writeFatal(c, LevelError, msg, nil)
+	exit(msg)
+	return v
+}
+
+// flushOutputs flushes the output writers of all log levels which implement a
+// Flush() error method (e.g. bufio.Writer) or a Sync() error method (e.g.
+// os.File). Errors are ignored, as the application is about to exit.
+func flushOutputs() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	for _, sinks := range [][]sink{debugOutputs, infoOutputs, warnOutputs, errorOutputs} {
+		for _, s := range sinks {
+			switch w := s.w.(type) {
+			case interface{ Flush() error }:
+				_ = w.Flush()
+			case interface{ Sync() error }:
+				_ = w.Sync()
+			}
+		}
+	}
+}
+
+// captureFatalStackTrace returns a stack trace to output beneath a fatal error
+// message; or nil if stack traces are disabled or the fatal behavior is
+// FatalReturn.
+func captureFatalStackTrace() []byte {
+	outputMutex.Lock()
+	enabled, all := fatalStackTrace && fatalBehavior != FatalReturn, fatalStackTraceAll
+	outputMutex.Unlock()
+	if !enabled {
+		return nil
 	}
-	if pkgLevel, ok := PathLevel(pkgPath); ok {
-		return pkgLevel > cur
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
 	}
-	return false
 }
 
-// --- [ debug ] ---------------------------------------------------------------
-
-// outputMutex is a mutex for concurrent writes to output writers.
-var outputMutex sync.Mutex
-
-var (
-	// debugOutput specifies the output writer of debug messages.
-	debugOutput io.Writer = os.Stderr
-
-	// debugUsePrefix specifies whether to use a prefix for debug messages.
-	debugUsePrefix = true
-)
-
-// SetDebugOutput sets the output writer of debug messages.
-func SetDebugOutput(w io.Writer) {
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	debugOutput = w
+// Error outputs the given error message to standard error. Unlike Fatal, Error
+// does not terminate the application.
+func Error(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, fmt.Sprint(args...), nil)
 }
 
-// SetDebugPrefix sets whether to use a prefix for debug messages.
-func SetDebugPrefix(usePrefix bool) {
-	debugUsePrefix = usePrefix
+// Errorf outputs the given error message to standard error. Unlike Fatalf,
+// Errorf does not terminate the application.
+func Errorf(format string, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, fmt.Sprintf(format, args...), nil)
 }
 
-// Debug outputs the given debug message to standard error.
-func Debug(args ...any) {
-	if skip(LevelDebug) {
+// Errorln outputs the given error message to standard error. Unlike Fatalln,
+// Errorln does not terminate the application.
+func Errorln(args ...any) {
+	if disabled(LevelError) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if debugUsePrefix {
-		prefix := getPrefix(term.MagentaBold)
-		fmt.Fprint(debugOutput, prefix)
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
 	}
-	fmt.Fprint(debugOutput, args...)
-	fmt.Fprintln(debugOutput)
+	write(c, LevelError, sprintln(args...), nil)
 }
 
-// Debugf outputs the given debug message to standard error.
-func Debugf(format string, args ...any) {
-	if skip(LevelDebug) {
+// ErrorString outputs the given error message to standard error. It is
+// equivalent to Error(s) for a single string argument, but avoids formatting
+// the message using package fmt. Unlike Fatal, ErrorString does not terminate
+// the application.
+func ErrorString(s string) {
+	if disabled(LevelError) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if debugUsePrefix {
-		prefix := getPrefix(term.MagentaBold)
-		fmt.Fprint(debugOutput, prefix)
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
 	}
-	fmt.Fprintf(debugOutput, format, args...)
-	fmt.Fprintln(debugOutput)
+	write(c, LevelError, s, nil)
 }
 
-// Debugln outputs the given debug message to standard error.
-func Debugln(args ...any) {
-	if skip(LevelDebug) {
+// ErrorBlock outputs the given lines as error messages to standard error, as a
+// single block which is not interleaved with log messages of concurrent
+// callers. The prefix is included on every line if prefixAll is set, and on the
+// first line only otherwise.
+func ErrorBlock(lines []string, prefixAll bool) {
+	if disabled(LevelError) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if debugUsePrefix {
-		prefix := getPrefix(term.MagentaBold)
-		fmt.Fprint(debugOutput, prefix)
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
 	}
-	fmt.Fprintln(debugOutput, args...)
+	writeBlock(c, LevelError, lines, prefixAll)
 }
 
-// --- [ info ] ----------------------------------------------------------------
+// Panic outputs the given error message to standard error and panics with the
+// message. The output is subject to path level filtering, but the panic always
+// occurs.
+func Panic(args ...any) {
+	c := getCaller()
+	msg := fmt.Sprint(args...)
+	if !skip(c, LevelError) {
+		writeRecord(newRecord(c, LevelError, msg, nil))
+	}
+	panic(msg)
+}
 
-var (
-	// infoOutput specifies the output writer of info messages.
-	infoOutput io.Writer = os.Stderr
+// Panicf outputs the given error message to standard error and panics with the
+// message. The output is subject to path level filtering, but the panic always
+// occurs.
+func Panicf(format string, args ...any) {
+	c := getCaller()
+	msg := fmt.Sprintf(format, args...)
+	if !skip(c, LevelError) {
+		writeRecord(newRecord(c, LevelError, msg, nil))
+	}
+	panic(msg)
+}
 
-	// infoUsePrefix specifies whether to use a prefix for info messages.
-	infoUsePrefix = true
-)
+// Panicln outputs the given error message to standard error and panics with the
+// message. The output is subject to path level filtering, but the panic always
+// occurs.
+func Panicln(args ...any) {
+	c := getCaller()
+	msg := sprintln(args...)
+	if !skip(c, LevelError) {
+		writeRecord(newRecord(c, LevelError, msg, nil))
+	}
+	panic(msg)
+}
 
-// SetInfoOutput sets the output writer of info messages.
-func SetInfoOutput(w io.Writer) {
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	infoOutput = w
+// Fatal outputs the given fatal error message to standard error and terminates
+// the application.
+func Fatal(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := fmt.Sprint(args...)
+	writeFatal(c, LevelError, msg, nil)
+	exit(msg)
 }
 
-// SetInfoPrefix sets whether to use a prefix for info messages.
-func SetInfoPrefix(usePrefix bool) {
-	infoUsePrefix = usePrefix
+// Fatalf outputs the given fatal error message to standard error and terminates
+// the application.
+func Fatalf(format string, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	writeFatal(c, LevelError, msg, nil)
+	exit(msg)
 }
 
-// Info outputs the given info message to standard error.
-func Info(args ...any) {
-	if skip(LevelInfo) {
+// Fatalln outputs the given fatal error message to standard error and
+// terminates the application.
+func Fatalln(args ...any) {
+	if disabled(LevelError) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if infoUsePrefix {
-		prefix := getPrefix(term.CyanBold)
-		fmt.Fprint(infoOutput, prefix)
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
 	}
-	fmt.Fprint(infoOutput, args...)
-	fmt.Fprintln(infoOutput)
+	msg := sprintln(args...)
+	writeFatal(c, LevelError, msg, nil)
+	exit(msg)
 }
 
-// Infof outputs the given info message to standard error.
-func Infof(format string, args ...any) {
-	if skip(LevelInfo) {
+// FatalCode outputs the given fatal error message to standard error and
+// terminates the application with the given exit code, overriding the error
+// exit code (see SetErrorExitCode).
+//
+// Example usage:
+//
+//	clog.FatalCode(2, "invalid command line arguments")
+func FatalCode(code int, args ...any) {
+	if disabled(LevelError) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if infoUsePrefix {
-		prefix := getPrefix(term.CyanBold)
-		fmt.Fprint(infoOutput, prefix)
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
 	}
-	fmt.Fprintf(infoOutput, format, args...)
-	fmt.Fprintln(infoOutput)
+	msg := fmt.Sprint(args...)
+	writeFatal(c, LevelError, msg, nil)
+	exitCode(code, msg)
 }
 
-// Infoln outputs the given info message to standard error.
-func Infoln(args ...any) {
-	if skip(LevelInfo) {
+// --- [ log ] -----------------------------------------------------------------
+
+// Log outputs the given message of the given log level, using the output
+// writers, prefix and color of the log level. Log messages of LevelFatal (or
+// above) terminate the application after output; other log levels never do.
+//
+// Example usage:
+//
+//	level := clog.LevelWarn
+//	if status >= 500 {
+//		level = clog.LevelError
+//	}
+//	clog.Log(level, "request failed with status ", status)
+func Log(level Level, args ...any) {
+	if disabled(level) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if infoUsePrefix {
-		prefix := getPrefix(term.CyanBold)
-		fmt.Fprint(infoOutput, prefix)
+	c := getCaller()
+	if level >= LevelFatal {
+		if skipFatal(c, level) {
+			return
+		}
+		msg := fmt.Sprint(args...)
+		writeFatal(c, level, msg, nil)
+		exit(msg)
+		return
 	}
-	fmt.Fprintln(infoOutput, args...)
+	if skip(c, level) {
+		return
+	}
+	write(c, level, fmt.Sprint(args...), nil)
 }
 
-// --- [ warning ] -------------------------------------------------------------
+// Logf outputs the given message of the given log level, using the output
+// writers, prefix and color of the log level. Log messages of LevelFatal (or
+// above) terminate the application after output; other log levels never do.
+func Logf(level Level, format string, args ...any) {
+	if disabled(level) {
+		return
+	}
+	c := getCaller()
+	if level >= LevelFatal {
+		if skipFatal(c, level) {
+			return
+		}
+		msg := fmt.Sprintf(format, args...)
+		writeFatal(c, level, msg, nil)
+		exit(msg)
+		return
+	}
+	if skip(c, level) {
+		return
+	}
+	write(c, level, fmt.Sprintf(format, args...), nil)
+}
 
-var (
-	// warnOutput specifies the output writer of non-fatal warning messages.
-	warnOutput io.Writer = os.Stderr
+// --- [ caller ] --------------------------------------------------------------
 
-	// warnUsePrefix specifies whether to use a prefix for warning messages.
-	warnUsePrefix = true
-)
+// callerSkip specifies the number of additional call frames to skip when
+// locating the caller of log functions.
+var callerSkip atomic.Int64
 
-// SetWarnOutput sets the output writer of non-fatal warning messages.
-func SetWarnOutput(w io.Writer) {
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	warnOutput = w
+// SetCallerSkip sets the number of additional call frames to skip when locating
+// the caller of log functions (default 0). This allows wrappers around log
+// functions to report the caller of the wrapper, rather than the wrapper
+// itself, for path level filtering and prefixes.
+//
+// Example usage:
+//
+//	clog.SetCallerSkip(1)
+//
+//	func logErr(err error) {
+//		clog.Warnf("%v", err) // reported as the caller of logErr.
+//	}
+//
+// Note, the offset is global and applies to every subsequent log call,
+// including log calls which are not made through a wrapper.
+func SetCallerSkip(n int) {
+	callerSkip.Store(int64(n))
 }
 
-// SetWarnPrefix sets whether to use a prefix for warning messages.
-func SetWarnPrefix(usePrefix bool) {
-	warnUsePrefix = usePrefix
+// ### [ Helper functions ] ####################################################
+
+// caller specifies the source location of the caller of a log function.
+type caller struct {
+	// Path-qualified function name of the caller; or empty if unknown.
+	name string
+	// File name and line number of the caller; or empty if unknown.
+	file string
+	line int
 }
 
-// Warn outputs the given non-fatal warning message to standard error.
-func Warn(args ...any) {
-	if skip(LevelWarn) {
+// write outputs the log message of the given log level, emitted by the given
+// caller and followed by the structured fields of the given log entry (if any),
+// to the output writers of the log level. The log message is subject to
+// sampling (see SetSampling).
+func write(c caller, level Level, msg string, e *Entry) {
+	if !sampled(level) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if warnUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(warnOutput, prefix)
+	writeRecord(newRecord(c, level, msg, e))
+}
+
+// writeFatal outputs the fatal error message of the given log level, emitted by
+// the given caller and followed by the structured fields of the given log entry
+// (if any), to the output writers of the log level. Fatal error messages are
+// exempt from sampling, as the application is about to terminate (or panic).
+// The stack trace (if enabled) is captured before output, and output beneath
+// the fatal error message. Nothing is output in count-only mode.
+func writeFatal(c caller, level Level, msg string, e *Entry) {
+	if countOnly.Load() {
+		return
 	}
-	fmt.Fprint(warnOutput, args...)
-	fmt.Fprintln(warnOutput)
+	r := newRecord(c, level, msg, e)
+	r.stack = captureFatalStackTrace()
+	writeRecord(r)
 }
 
-// Warnf outputs the given non-fatal warning message to standard error.
-func Warnf(format string, args ...any) {
-	if skip(LevelWarn) {
+// writeRecord outputs the given log message to the output writers of its log
+// level, and notifies hooks and subscribers.
+func writeRecord(r *record) {
+	outputRecords([]*record{r}, true)
+	reportWriteErrors()
+	runHooks(r)
+	notifySubscribers(r)
+}
+
+// writeBlock outputs the given lines as log messages of the given log level,
+// emitted by the given caller, to the output writers of the log level. The
+// lines are written as a single block, which is not interleaved with log
+// messages of concurrent callers. The prefix is included on the first line
+// only, unless prefixAll is set.
+func writeBlock(c caller, level Level, lines []string, prefixAll bool) {
+	if len(lines) == 0 || !sampled(level) {
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if warnUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(warnOutput, prefix)
+	first := newRecord(c, level, lines[0], nil)
+	rs := []*record{first}
+	for _, line := range lines[1:] {
+		r := *first
+		r.msg = limitMessage(redact(line))
+		rs = append(rs, &r)
+	}
+	outputRecords(rs, prefixAll)
+	reportWriteErrors()
+	for _, r := range rs {
+		runHooks(r)
+		notifySubscribers(r)
 	}
-	fmt.Fprintf(warnOutput, format, args...)
-	fmt.Fprintln(warnOutput)
 }
 
-// Warnln outputs the given non-fatal warning message to standard error.
-func Warnln(args ...any) {
-	if skip(LevelWarn) {
-		return
+// newRecord returns a new log message of the given log level, emitted by the
+// given caller and carrying the structured fields of the given log entry (if
+// any).
+func newRecord(c caller, level Level, msg string, e *Entry) *record {
+	r := &record{caller: c, level: level, msg: limitMessage(redact(msg))}
+	if e != nil {
+		r.fields, r.tag = e.fields, e.prefix
+	}
+	if useGoroutineID.Load() {
+		r.goroutineID = goroutineID()
 	}
+	r.indent = indentDepth()
+	r.traceID = traceID()
+	return r
+}
+
+// limitMessage truncates the given log message to the maximum message length
+// (if set).
+func limitMessage(msg string) string {
+	if n := int(maxMessageLength.Load()); n > 0 && len(msg) > n {
+		return truncate(msg, n)
+	}
+	return msg
+}
+
+// outputRecords outputs the given log messages of the same log level as a
+// single block to the output writers of their log level. The prefix is included
+// on the first log message only, unless prefixAll is set.
+//
+// The time of the log messages is set while holding outputMutex, so that log
+// messages are output in chronological order.
+func outputRecords(rs []*record, prefixAll bool) {
 	outputMutex.Lock()
 	defer outputMutex.Unlock()
-	if warnUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(warnOutput, prefix)
+	t := now()
+	for _, r := range rs {
+		r.time = t
+	}
+	level := rs[0].level
+	sinks, usePrefix := levelOutput(level)
+	if quiet {
+		usePrefix = false
+	}
+	if len(rs) == 1 && dedupWindow > 0 && dedupSuppress(rs[0]) {
+		return
+	}
+	if useSequence {
+		for _, r := range rs {
+			sequence++
+			r.seq = sequence
+		}
+	}
+	separate := false
+	if burstGap > 0 {
+		separate = !lastOutputTime.IsZero() && t.Sub(lastOutputTime) > burstGap
+		lastOutputTime = t
+	}
+	if formatter != nil {
+		if block, ok := formatBlock(rs); ok {
+			for _, s := range sinks {
+				output(s.w, block)
+			}
+			writeStackTrace(rs)
+			return
+		}
 	}
-	fmt.Fprintln(warnOutput, args...)
+	// format colored and uncolored blocks at most once each.
+	var colorBlock, plainBlock []byte
+	for _, s := range sinks {
+		if separate {
+			output(s.w, []byte("\n"))
+		}
+		if (s.useColor || forceColor) && !quiet && !disableColor {
+			if colorBlock == nil {
+				colorBlock = formatLines(rs, usePrefix, prefixAll, true)
+			}
+			output(s.w, colorBlock)
+		} else {
+			if plainBlock == nil {
+				plainBlock = formatLines(rs, usePrefix, prefixAll, false)
+			}
+			output(s.w, plainBlock)
+		}
+	}
+	writeStackTrace(rs)
 }
 
-// --- [ error ] ---------------------------------------------------------------
+// writeStackTrace outputs the stack traces of the given log messages (if any)
+// uncolored to the output writers of error messages.
+//
+// Note, outputMutex must be held by the caller.
+func writeStackTrace(rs []*record) {
+	for _, r := range rs {
+		if r.stack == nil {
+			continue
+		}
+		for _, s := range errorOutputs {
+			output(s.w, r.stack)
+		}
+	}
+}
 
-var (
-	// errorOutput specifies the output writer of fatal error messages.
-	errorOutput io.Writer = os.Stderr
+// formatBlock formats the given log messages using the formatter, one per
+// line, and reports whether successful. On failure, the message of the log
+// message which could not be formatted is annotated with the error, for
+// fallback to the default text output.
+//
+// Note, outputMutex must be held by the caller.
+func formatBlock(rs []*record) ([]byte, bool) {
+	var block []byte
+	for _, r := range rs {
+		pkgName := getPkgName(r.caller.name)
+		data, err := formatter.Format(r.level, pkgName, r.msg, formatterFields(r))
+		if err != nil {
+			// fall back to default text output if unable to format message.
+			r.msg += fmt.Sprintf(" (unable to format message: %v)", err)
+			return nil, false
+		}
+		block = append(block, data...)
+		block = append(block, '\n')
+	}
+	return block, true
+}
 
-	// errorUsePrefix specifies whether to use a prefix for error messages.
-	errorUsePrefix = true
-)
+// formatLines formats the given log messages using the default text output.
+// The prefix is included on the first log message only, unless prefixAll is
+// set.
+//
+// Note, outputMutex must be held by the caller.
+func formatLines(rs []*record, usePrefix, prefixAll, useColor bool) []byte {
+	if len(rs) == 1 {
+		return formatLine(rs[0], usePrefix, useColor)
+	}
+	var block []byte
+	for i, r := range rs {
+		block = append(block, formatLine(r, usePrefix && (prefixAll || i == 0), useColor)...)
+	}
+	return block
+}
 
-// SetErrorOutput sets the output writer of fatal error messages.
-func SetErrorOutput(w io.Writer) {
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	errorOutput = w
+// record is a log message pending output.
+type record struct {
+	// Caller of the log function.
+	caller caller
+	// Log level of the log message.
+	level Level
+	// Time of the log message; set when output.
+	time time.Time
+	// Log message.
+	msg string
+	// Goroutine ID of the caller; or 0 if not included in prefixes.
+	goroutineID uint64
+	// Tag of the log entry; or empty if untagged.
+	tag string
+	// Indentation depth of the log message.
+	indent int
+	// Sequence number of the log message; or 0 if not included in prefixes.
+	seq uint64
+	// Trace ID of the caller; or empty if not set.
+	traceID string
+	// Structured fields of the log entry.
+	fields Fields
+	// Stack trace output beneath a fatal error message; or nil if none.
+	stack []byte
 }
 
-// SetErrorPrefix sets whether to use a prefix for error messages.
-func SetErrorPrefix(usePrefix bool) {
-	errorUsePrefix = usePrefix
+// formatLine returns the log line of the given log message in the default text
+// format, with or without prefix and color.
+//
+// Note, outputMutex must be held by the caller.
+func formatLine(r *record, usePrefix, useColor bool) []byte {
+	buf := &bytes.Buffer{}
+	if usePrefix {
+		prefixColor, fileLineColor, dimColor, tagColor := levelColor(r.level), term.WhiteBold, dim, term.BlueBold
+		if !useColor {
+			prefixColor, fileLineColor, dimColor, tagColor = noColor, noColor, noColor, noColor
+		}
+		if r.seq != 0 {
+			buf.WriteString(getSequence(r.seq, dimColor))
+		}
+		if useTimestamps {
+			buf.WriteString(getTimestamp(r.time, dimColor))
+		}
+		if useElapsed {
+			buf.WriteString(getElapsed(r.time, dimColor))
+		}
+		if r.goroutineID != 0 {
+			buf.WriteString(getGoroutineID(r.goroutineID, dimColor))
+		}
+		if len(r.traceID) > 0 {
+			buf.WriteString(getTraceID(r.traceID, dimColor))
+		}
+		if len(serviceName) > 0 {
+			buf.WriteString(getServiceName(tagColor))
+		}
+		if fn, ok := prefixFormats[r.level]; ok {
+			buf.WriteString(getCustomPrefix(r.caller, fn, prefixColor))
+		} else {
+			buf.WriteString(getPrefix(r.caller, prefixColor))
+			if levelFileLine(r.level) {
+				buf.WriteString(getFileLine(r.caller, fileLineColor, useColor && useFileLineHyperlinks))
+			}
+		}
+		if len(r.tag) > 0 {
+			buf.WriteString(getTag(r.tag, tagColor))
+		}
+	}
+	if r.indent > 0 {
+		buf.WriteString(getIndent(r.indent))
+	}
+	buf.WriteString(r.msg)
+	if len(r.fields) > 0 {
+		buf.WriteString(" ")
+		keyColor, valColor := fieldKeyColor, fieldValColor
+		if !useColor {
+			keyColor, valColor = noColor, noColor
+		}
+		buf.WriteString(formatFields(r.fields, keyColor, valColor))
+	}
+	if autoNewline {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
 }
 
-// Fatal outputs the given fatal error message to standard error and terminates
-// the application.
-func Fatal(args ...any) {
-	if skip(LevelError) {
+// output writes the given log line to w, or queues the log line for output if
+// asynchronous mode is enabled.
+//
+// Each log line (prefix, message, fields and trailing newline) is assembled in
+// a single buffer and written using a single Write call, so that log lines of
+// concurrent callers sharing an output writer do not interleave. Errors of the
+// output writer are reported to the write error handler once outputMutex has
+// been released (see SetWriteErrorHandler).
+//
+// Note, outputMutex must be held by the caller.
+func output(w io.Writer, line []byte) {
+	if asyncLines != nil {
+		asyncLines <- asyncLine{w: w, line: line}
 		return
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if errorUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(errorOutput, prefix)
+	if _, err := w.Write(line); err != nil {
+		recordWriteError(err)
 	}
-	fmt.Fprint(errorOutput, args...)
-	fmt.Fprintln(errorOutput)
-	os.Exit(1)
 }
 
-// Fatalf outputs the given fatal error message to standard error and terminates
-// the application.
-func Fatalf(format string, args ...any) {
-	if skip(LevelError) {
-		return
+// levelOutput returns the output writers of the given log level, and a boolean
+// indicating whether to use a prefix for messages of the log level.
+//
+// Note, outputMutex must be held by the caller.
+func levelOutput(level Level) (sinks []sink, usePrefix bool) {
+	switch {
+	case level < LevelInfo:
+		return debugOutputs, debugUsePrefix
+	case level < LevelWarn:
+		return infoOutputs, infoUsePrefix
+	case level < LevelError:
+		return warnOutputs, warnUsePrefix
+	default:
+		return errorOutputs, errorUsePrefix
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if errorUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(errorOutput, prefix)
+}
+
+// setLevelOutput sets the output writer of messages of the given log level.
+//
+// Note, outputMutex must be held by the caller.
+func setLevelOutput(level Level, w io.Writer) {
+	sinks := []sink{newSink(w)}
+	switch {
+	case level < LevelInfo:
+		debugOutputs = sinks
+	case level < LevelWarn:
+		infoOutputs = sinks
+	case level < LevelError:
+		warnOutputs = sinks
+	default:
+		errorOutputs = sinks
 	}
-	fmt.Fprintf(errorOutput, format, args...)
-	fmt.Fprintln(errorOutput)
-	os.Exit(1)
 }
 
-// Fatalln outputs the given fatal error message to standard error and
-// terminates the application.
-func Fatalln(args ...any) {
-	if skip(LevelError) {
-		return
+// sinksWriter returns a writer which writes to the output writers of the given
+// sinks.
+func sinksWriter(sinks []sink) io.Writer {
+	if len(sinks) == 1 {
+		return sinks[0].w
 	}
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
-	if errorUsePrefix {
-		prefix := getPrefix(term.RedBold)
-		prefix += getFileLine()
-		fmt.Fprint(errorOutput, prefix)
+	ws := make([]io.Writer, len(sinks))
+	for i, s := range sinks {
+		ws[i] = s.w
 	}
-	fmt.Fprintln(errorOutput, args...)
-	os.Exit(1)
+	return io.MultiWriter(ws...)
 }
 
-// ### [ Helper functions ] ####################################################
+// levelFileLine reports whether to include file:line in the prefix of messages
+// of the given log level.
+//
+// Note, outputMutex must be held by the caller.
+func levelFileLine(level Level) bool {
+	switch {
+	case level < LevelInfo:
+		return debugUseFileLine
+	case level < LevelWarn:
+		return infoUseFileLine
+	default:
+		return true
+	}
+}
+
+// levelColor returns the terminal color used for the prefix of the given log
+// level.
+//
+// Note, outputMutex must be held by the caller.
+func levelColor(level Level) func(string) string {
+	if colorFunc, ok := levelColors[level]; ok {
+		return colorFunc
+	}
+	switch {
+	case level < LevelInfo:
+		return term.MagentaBold
+	case level < LevelWarn:
+		return term.CyanBold
+	default:
+		return term.RedBold
+	}
+}
+
+// truncate truncates the given log message to at most n bytes, without
+// splitting multibyte UTF-8 characters, and appends the number of truncated
+// bytes.
+func truncate(msg string, n int) string {
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s…(truncated, %d bytes)", msg[:n], len(msg)-n)
+}
+
+// errMsg returns the log message of the given arguments followed by err (e.g.
+// "unable to open file: permission denied").
+func errMsg(err error, args ...any) string {
+	msg := fmt.Sprint(args...)
+	if len(msg) == 0 {
+		return err.Error()
+	}
+	return msg + ": " + err.Error()
+}
+
+// noColor returns the given text without terminal color.
+func noColor(text string) string {
+	return text
+}
 
-// getQualifiedPaths returns the qualified package and and qualified function
-// paths of the caller.
-func getQualifiedPaths() (pkgPath, funcPath string) {
-	const skip = 3 // skip 3 call frames: {Debugf,Warnf}, skip and getQualifiedPaths.
-	pathQualifiedName, _, _, ok := callerName(skip)
+// isTerminal reports whether the given output writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
 	if !ok {
-		return "", ""
+		return false
 	}
-	funcPath = pathQualifiedName
-	pkgPath = getPkgPath(funcPath)
-	return pkgPath, funcPath
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// sprintln formats the given arguments like fmt.Sprintln, but without the
+// trailing newline.
+func sprintln(args ...any) string {
+	s := fmt.Sprintln(args...)
+	return s[:len(s)-1]
 }
 
 // getPrefix returns the prefix used for logging based on the function name of
 // the caller and the given terminal color.
-func getPrefix(colorFunc func(string) string) string {
-	const skip = 2 // skip 2 call frames: {Debugf,Warnf} and getPrefix.
-	pathQualifiedName, _, _, ok := callerName(skip)
-	if !ok {
+//
+// Note, outputMutex must be held by the caller.
+func getPrefix(c caller, colorFunc func(string) string) string {
+	if len(c.name) == 0 {
 		return ""
 	}
-	pkgName := getPkgName(pathQualifiedName)
-	prefix := colorFunc(pkgName+":") + " "
+	name := getPrefixPkg(c.name)
+	if useModuleVersion {
+		if version := moduleVersion(getPkgPath(c.name)); len(version) > 0 {
+			name += "@" + version
+		}
+	}
+	if useFuncInPrefix {
+		name += "." + getFuncName(c.name)
+	}
+	if usePrefixBrackets {
+		name = "[" + name + "]"
+	}
+	sep := strings.TrimRightFunc(prefixSeparator, unicode.IsSpace)
+	space := prefixSeparator[len(sep):]
+	prefix := colorFunc(name+sep) + space
+	// pad uncolored prefix to the prefix width.
+	if n := prefixPadding - utf8.RuneCountInString(name+prefixSeparator); n > 0 {
+		prefix += strings.Repeat(" ", n)
+	}
 	return prefix
 }
 
-// getFileLine returns the file name and line number of the caller.
-func getFileLine() string {
-	const skip = 2 // skip 2 call frames: {Debugf,Warnf} and getFileLine.
-	_, file, line, ok := callerName(skip)
-	if !ok {
+// getFileLine returns the file name and line number of the caller, using the
+// given terminal color; rendered as a terminal hyperlink to the source file if
+// link is set.
+//
+// Note, outputMutex must be held by the caller.
+func getFileLine(c caller, colorFunc func(string) string, link bool) string {
+	if len(c.file) == 0 {
 		return ""
 	}
+	file := c.file
+	if useShortFile {
+		file = filepath.Base(file)
+	}
 	// TODO: use getFuncName?
-	s := fmt.Sprintf("%s:%d", file, line)
-	fileLine := term.WhiteBold(s+":") + " "
+	s := fmt.Sprintf("%s:%d", file, c.line)
+	if link {
+		s = hyperlink(s, c.file, c.line)
+	}
+	fileLine := colorFunc(s+":") + " "
 	return fileLine
 }
 
-// callerName returns the path-qualified function name of the caller.
-func callerName(skip int) (pathQualifiedName string, fileName string, lineNum int, ok bool) {
+// getCaller returns the source location of the caller of the log function
+// calling getCaller. The additional call frames of SetCallerSkip are skipped as
+// well.
+func getCaller() caller {
 	var pcs [1]uintptr
-	n := runtime.Callers(skip+2, pcs[:]) // always skip the 2 deepest call frames: callerName and runtime.Callers
+	// skip 3 call frames: runtime.Callers, getCaller and {Debugf,Warnf}.
+	skip := 3 + int(callerSkip.Load())
+	n := runtime.Callers(skip, pcs[:])
 	if n != len(pcs) {
-		// unable to get program counter of callers
-		return "", "", 0, false
+		// unable to get program counter of caller
+		return caller{}
 	}
-	fn := runtime.FuncForPC(pcs[0])
-	if fn == nil {
-		// unable to get function with program counter pcs[0]
-		return "", "", 0, false
+	return callerFromPC(pcs[0])
+}
+
+// callerFromPC returns the source location of the given program counter, as
+// returned by runtime.Callers. Inlined functions are accounted for.
+func callerFromPC(pc uintptr) caller {
+	if pc == 0 {
+		return caller{}
 	}
-	pathQualifiedName = fn.Name()
-	fileName, lineNum = fn.FileLine(pcs[0])
-	return pathQualifiedName, fileName, lineNum, true
+	frames := runtime.CallersFrames([]uintptr{pc})
+	frame, _ := frames.Next()
+	return caller{name: frame.Function, file: frame.File, line: frame.Line}
 }
 
 // getPkgPath returns the package path of the path-qualified function name.
 //
 // Example input:
 //
-//	github.com/mewpkg/clog.getPrefix
+//	github.com/mewpkg/clog.getCaller
 //	github.com/mewpkg/clog.Debugf
+//	github.com/user/repo/pkg.(*Server).Handle
+//	github.com/user/repo/pkg.Server.String
+//	github.com/user/repo/pkg.(*Cache[...]).Get
+//	github.com/user/repo/pkg.Map[go.shape.string,github.com/user/repo/pkg/sub.T]
 //	main.main
 //
 // Example output:
 //
 //	github.com/mewpkg/clog
 //	github.com/mewpkg/clog
+//	github.com/user/repo/pkg
+//	github.com/user/repo/pkg
+//	github.com/user/repo/pkg
+//	github.com/user/repo/pkg
 //	main
 func getPkgPath(name string) string {
 	// find last slash of package path.
 	end := 0
-	pos := strings.LastIndex(name, "/")
+	pos := lastSlash(name)
 	if pos != -1 {
 		end = pos + 1
 	}
-	// strip function name.
+	// strip function name, including method receiver (e.g. "(*Server).Handle");
+	// receivers always follow the first dot after the package path.
 	pos = strings.Index(name[end:], ".")
 	if pos != -1 {
 		end += pos
@@ -439,22 +2178,73 @@ func getPkgPath(name string) string {
 	return name[:end]
 }
 
+// parentDir returns the parent directory of the given package path, or the
+// package path itself if it has no parent directory.
+//
+// Package paths always use forward slashes, so path.Dir is used rather than
+// filepath.Dir (which splits on backslashes on Windows).
+//
+// Example input:
+//
+//	github.com/user/repo/pkg/sub
+//	github.com/user/repo
+//	main
+//
+// Example output:
+//
+//	github.com/user/repo/pkg
+//	github.com/user
+//	main
+func parentDir(pkgPath string) string {
+	dir := path.Dir(pkgPath)
+	if dir == "." || dir == "/" {
+		return pkgPath
+	}
+	return dir
+}
+
+// isGlob reports whether the given path is a glob pattern.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// matchGlob reports whether the given path name matches the glob pattern.
+// Malformed patterns match no paths.
+func matchGlob(pattern, name string) bool {
+	match, _ := path.Match(pattern, name)
+	return match
+}
+
+// globSpecificity returns the specificity of the given glob pattern, as the
+// number of non-wildcard characters.
+func globSpecificity(pattern string) int {
+	return len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+}
+
 // getPkgName returns the package name of the path-qualified function name.
 //
 // Example input:
 //
-//	github.com/mewpkg/clog.getPrefix
+//	github.com/mewpkg/clog.getCaller
 //	github.com/mewpkg/clog.Debugf
+//	github.com/user/repo/pkg.(*Server).Handle
+//	github.com/user/repo/pkg.Server.String
+//	github.com/user/repo/pkg.(*Cache[...]).Get
+//	github.com/user/repo/pkg.Map[go.shape.string,github.com/user/repo/pkg/sub.T]
 //	main.main
 //
 // Example output:
 //
 //	clog
 //	clog
+//	pkg
+//	pkg
+//	pkg
+//	pkg
 //	main
 func getPkgName(name string) string {
 	// strip package path; keep package name and function name.
-	pos := strings.LastIndex(name, "/")
+	pos := lastSlash(name)
 	if pos != -1 {
 		name = name[pos+1:]
 	}
@@ -470,18 +2260,26 @@ func getPkgName(name string) string {
 //
 // Example input:
 //
-//	github.com/mewpkg/clog.getPrefix
+//	github.com/mewpkg/clog.getCaller
 //	github.com/mewpkg/clog.Debugf
+//	github.com/user/repo/pkg.(*Server).Handle
+//	github.com/user/repo/pkg.Server.String
+//	github.com/user/repo/pkg.(*Cache[...]).Get
+//	github.com/user/repo/pkg.Map[go.shape.string,github.com/user/repo/pkg/sub.T]
 //	main.main
 //
 // Example output:
 //
-//	getPrefix
+//	getCaller
 //	Debugf
+//	(*Server).Handle
+//	Server.String
+//	(*Cache[...]).Get
+//	Map[go.shape.string,github.com/user/repo/pkg/sub.T]
 //	main
 func getFuncName(name string) string {
 	// strip package path; keep package name and function name.
-	pos := strings.LastIndex(name, "/")
+	pos := lastSlash(name)
 	if pos != -1 {
 		name = name[pos+1:]
 	}
@@ -492,3 +2290,24 @@ func getFuncName(name string) string {
 	}
 	return name
 }
+
+// lastSlash returns the index of the last slash of the given path-qualified
+// function name which is not enclosed in the brackets of type arguments of
+// generic functions and types (e.g. "[github.com/user/repo/pkg.T]"), or -1 if
+// not present.
+func lastSlash(name string) int {
+	pos, depth := -1, 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				pos = i
+			}
+		}
+	}
+	return pos
+}
@@ -0,0 +1,543 @@
+package clog
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetPkgPath(t *testing.T) {
+	golden := []struct {
+		name     string
+		pkgPath  string
+		pkgName  string
+		funcName string
+	}{
+		// plain functions.
+		{
+			name:     "github.com/mewpkg/clog.Debugf",
+			pkgPath:  "github.com/mewpkg/clog",
+			pkgName:  "clog",
+			funcName: "Debugf",
+		},
+		{
+			name:     "main.main",
+			pkgPath:  "main",
+			pkgName:  "main",
+			funcName: "main",
+		},
+		{
+			name:     "github.com/user/repo/pkg.init.0",
+			pkgPath:  "github.com/user/repo/pkg",
+			pkgName:  "pkg",
+			funcName: "init.0",
+		},
+		{
+			name:     "github.com/user/repo/pkg.Handle.func1",
+			pkgPath:  "github.com/user/repo/pkg",
+			pkgName:  "pkg",
+			funcName: "Handle.func1",
+		},
+		// pointer receivers.
+		{
+			name:     "github.com/user/repo/pkg.(*Server).Handle",
+			pkgPath:  "github.com/user/repo/pkg",
+			pkgName:  "pkg",
+			funcName: "(*Server).Handle",
+		},
+		{
+			name:     "main.(*Server).Handle",
+			pkgPath:  "main",
+			pkgName:  "main",
+			funcName: "(*Server).Handle",
+		},
+		// value receivers.
+		{
+			name:     "github.com/user/repo/pkg.Server.String",
+			pkgPath:  "github.com/user/repo/pkg",
+			pkgName:  "pkg",
+			funcName: "Server.String",
+		},
+	}
+	for _, g := range golden {
+		if got := getPkgPath(g.name); got != g.pkgPath {
+			t.Errorf("%q: package path mismatch; expected %q, got %q", g.name, g.pkgPath, got)
+		}
+		if got := getPkgName(g.name); got != g.pkgName {
+			t.Errorf("%q: package name mismatch; expected %q, got %q", g.name, g.pkgName, got)
+		}
+		if got := getFuncName(g.name); got != g.funcName {
+			t.Errorf("%q: function name mismatch; expected %q, got %q", g.name, g.funcName, got)
+		}
+	}
+}
+
+func TestParentDir(t *testing.T) {
+	golden := []struct {
+		pkgPath string
+		want    string
+	}{
+		{pkgPath: "github.com/user/repo/pkg/sub", want: "github.com/user/repo/pkg"},
+		{pkgPath: "github.com/user/repo", want: "github.com/user"},
+		{pkgPath: "github.com", want: "github.com"},
+		{pkgPath: "main", want: "main"},
+	}
+	for _, g := range golden {
+		if got := parentDir(g.pkgPath); got != g.want {
+			t.Errorf("%q: parent directory mismatch; expected %q, got %q", g.pkgPath, g.want, got)
+		}
+	}
+}
+
+func TestPathLevelInheritance(t *testing.T) {
+	resetLevels(t)
+	SetPathLevel("github.com/user/repo/pkg", LevelWarn)
+	SetPathLevel("github.com/user/repo/pkg/a/b", LevelError)
+	golden := []struct {
+		path    string
+		want    Level
+		matched string
+		ok      bool
+	}{
+		{path: "github.com/user/repo/pkg", want: LevelWarn, matched: "github.com/user/repo/pkg", ok: true},
+		{path: "github.com/user/repo/pkg/sub", want: LevelWarn, matched: "github.com/user/repo/pkg", ok: true},
+		{path: "github.com/user/repo/pkg/a", want: LevelWarn, matched: "github.com/user/repo/pkg", ok: true},
+		{path: "github.com/user/repo/pkg/a/b/c/d", want: LevelError, matched: "github.com/user/repo/pkg/a/b", ok: true},
+		{path: "github.com/user/repo", ok: false},
+		{path: "github.com/user/repo/pkgx", ok: false},
+	}
+	for _, g := range golden {
+		level, matched, ok := ResolvePathLevel(g.path)
+		if ok != g.ok {
+			t.Errorf("%q: expected ok=%v, got ok=%v", g.path, g.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if level != g.want || matched != g.matched {
+			t.Errorf("%q: expected level %v from %q, got level %v from %q", g.path, g.want, g.matched, level, matched)
+		}
+		if got := callerLevel(g.path, g.path+".F"); got != g.want {
+			t.Errorf("%q: caller level mismatch; expected %v, got %v", g.path, g.want, got)
+		}
+	}
+}
+
+// resetLevels resets the global log level and path levels once the test
+// completes.
+func resetLevels(t testing.TB) {
+	t.Helper()
+	prev := GetLevel()
+	ResetPathLevels()
+	t.Cleanup(func() {
+		SetLevel(prev)
+		ResetPathLevels()
+	})
+}
+
+func TestCallerLevelPrecedence(t *testing.T) {
+	const (
+		app    = "github.com/me/app"
+		global = LevelFatal
+	)
+	golden := []struct {
+		// Path levels.
+		levels map[string]Level
+		// Function path of caller; the package path is derived from the function
+		// path.
+		funcPath string
+		want     Level
+	}{
+		// exact package path takes precedence over glob.
+		{
+			levels:   map[string]Level{app + "/internal/*": 2, app + "/internal/db": 4},
+			funcPath: app + "/internal/db.Open",
+			want:     4,
+		},
+		// more specific glob takes precedence over broader glob.
+		{
+			levels:   map[string]Level{app + "/internal/*": 2, app + "/internal/db*": 3},
+			funcPath: app + "/internal/dbx.Open",
+			want:     3,
+		},
+		{
+			levels:   map[string]Level{app + "/internal/*": 2, app + "/internal/db*": 3},
+			funcPath: app + "/internal/api.Serve",
+			want:     2,
+		},
+		// specificity is the number of non-wildcard characters.
+		{
+			levels:   map[string]Level{app + "/*/db": 2, app + "/internal/*": 3},
+			funcPath: app + "/internal/db.Open",
+			want:     3,
+		},
+		// "?" matches a single character.
+		{
+			levels:   map[string]Level{app + "/v?": 3},
+			funcPath: app + "/v2.Open",
+			want:     3,
+		},
+		// "*" does not match slashes.
+		{
+			levels:   map[string]Level{app + "/internal/*/db": 2},
+			funcPath: app + "/internal/x/y/db.Open",
+			want:     global,
+		},
+		{
+			levels:   map[string]Level{app + "/*": 2},
+			funcPath: "github.com/me/other/app/x.Open",
+			want:     global,
+		},
+		// exact function path takes precedence over exact package path.
+		{
+			levels:   map[string]Level{app + "/api": 1, app + "/api.Serve": 6},
+			funcPath: app + "/api.Serve",
+			want:     6,
+		},
+		{
+			levels:   map[string]Level{app + "/api": 1, app + "/api.Serve": 6},
+			funcPath: app + "/api.Handle",
+			want:     1,
+		},
+		// glob of function path.
+		{
+			levels:   map[string]Level{app + "/api.Handle*": 5},
+			funcPath: app + "/api.HandleGet",
+			want:     5,
+		},
+		{
+			levels:   map[string]Level{app + "/api.Handle*": 5},
+			funcPath: app + "/api.Serve",
+			want:     global,
+		},
+		{
+			levels:   map[string]Level{app + "/api.(*Server).*": 5},
+			funcPath: app + "/api.(*Server).Handle",
+			want:     5,
+		},
+		// exact function path takes precedence over glob of function path.
+		{
+			levels:   map[string]Level{app + "/api.Handle*": 5, app + "/api.HandleGet": 6},
+			funcPath: app + "/api.HandleGet",
+			want:     6,
+		},
+		// exact package path takes precedence over glob of function path.
+		{
+			levels:   map[string]Level{app + "/api": 1, app + "/api.Handle*": 5},
+			funcPath: app + "/api.HandleGet",
+			want:     1,
+		},
+		// glob takes precedence over parent directory.
+		{
+			levels:   map[string]Level{app: 1, app + "/internal/*": 2},
+			funcPath: app + "/internal/db.Open",
+			want:     2,
+		},
+		{
+			levels:   map[string]Level{app: 1, app + "/internal/*": 2},
+			funcPath: app + "/cmd.Run",
+			want:     1,
+		},
+		// nested sub-packages inherit from the closest parent directory, which
+		// may be matched by glob.
+		{
+			levels:   map[string]Level{app + "/internal/*": 2},
+			funcPath: app + "/internal/db/sub/deep.Open",
+			want:     2,
+		},
+		{
+			levels:   map[string]Level{app + "/internal/*": 2, app + "/internal/db/sub": 4},
+			funcPath: app + "/internal/db/sub/deep.Open",
+			want:     4,
+		},
+		{
+			levels:   map[string]Level{app + "/internal/db/*": 3, app + "/internal": 1},
+			funcPath: app + "/internal/db/sub/deep.(*T).Open",
+			want:     3,
+		},
+	}
+	for _, g := range golden {
+		resetLevels(t)
+		SetLevel(global)
+		for path, level := range g.levels {
+			SetPathLevel(path, level)
+		}
+		if got := callerLevel(getPkgPath(g.funcPath), g.funcPath); got != g.want {
+			t.Errorf("%q with path levels %v: expected level %v, got %v", g.funcPath, g.levels, g.want, got)
+		}
+	}
+}
+
+// BenchmarkCallerLevelParallel measures concurrent path level lookups of log
+// calls, which only take the read lock of mu. The "exclusive" benchmark
+// serializes lookups using an exclusive lock, for comparison.
+func BenchmarkCallerLevelParallel(b *testing.B) {
+	resetLevels(b)
+	SetLevel(LevelInfo)
+	SetPathLevel("github.com/me/app/internal/*", LevelWarn)
+	SetPathLevel("github.com/me/app/cmd", LevelDebug)
+	const (
+		pkgPath  = "github.com/me/app/internal/db/sub"
+		funcPath = pkgPath + ".(*DB).Query"
+	)
+	b.Run("shared", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				callerLevel(pkgPath, funcPath)
+			}
+		})
+	})
+	b.Run("exclusive", func(b *testing.B) {
+		var exclusive sync.Mutex
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				exclusive.Lock()
+				callerLevel(pkgPath, funcPath)
+				exclusive.Unlock()
+			}
+		})
+	})
+}
+
+func TestTimestampOrder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	var ms atomic.Int64
+	SetClock(func() time.Time {
+		return time.UnixMilli(ms.Add(1))
+	})
+	defer SetClock(nil)
+	SetTimestamps(true)
+	defer SetTimestamps(false)
+	SetTimeFormatUnixMillis()
+	defer SetTimeFormat("15:04:05.000")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Info("foo")
+				runtime.Gosched()
+			}
+		}()
+	}
+	wg.Wait()
+	prev := int64(0)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		field, _, _ := strings.Cut(line, " ")
+		cur, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			t.Fatalf("unable to parse timestamp of log line %q; %v", line, err)
+		}
+		if cur <= prev {
+			t.Fatalf("log lines out of chronological order; %d output after %d", cur, prev)
+		}
+		prev = cur
+	}
+}
+
+func TestGetPkgPathGeneric(t *testing.T) {
+	golden := []struct {
+		name     string
+		pkgPath  string
+		pkgName  string
+		funcName string
+	}{
+		{
+			name:     "github.com/me/app.Process[...]",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "Process[...]",
+		},
+		{
+			name:     "github.com/me/app.(*Cache[...]).Get",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "(*Cache[...]).Get",
+		},
+		{
+			name:     "github.com/me/app.(*Cache[string]).Get",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "(*Cache[string]).Get",
+		},
+		{
+			name:     "github.com/me/app.Pair[...].String",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "Pair[...].String",
+		},
+		{
+			name:     "github.com/me/app.Process[...].func1",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "Process[...].func1",
+		},
+		{
+			name:     "github.com/me/app.Map[go.shape.string,github.com/me/app/sub.T]",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "Map[go.shape.string,github.com/me/app/sub.T]",
+		},
+		{
+			name:     "github.com/me/app.(*Cache[go.shape.*github.com/me/app/sub.T]).Get",
+			pkgPath:  "github.com/me/app",
+			pkgName:  "app",
+			funcName: "(*Cache[go.shape.*github.com/me/app/sub.T]).Get",
+		},
+		{
+			name:     "main.Process[go.shape.int]",
+			pkgPath:  "main",
+			pkgName:  "main",
+			funcName: "Process[go.shape.int]",
+		},
+		// function names produced by the Go runtime.
+		{
+			name:     (&genericCache[string]{}).get(),
+			pkgPath:  "github.com/mewpkg/clog",
+			pkgName:  "clog",
+			funcName: "(*genericCache[...]).get",
+		},
+		{
+			name:     genericPair[int, string]{}.get(),
+			pkgPath:  "github.com/mewpkg/clog",
+			pkgName:  "clog",
+			funcName: "genericPair[...].get",
+		},
+		{
+			name:     genericFunc[[]byte](),
+			pkgPath:  "github.com/mewpkg/clog",
+			pkgName:  "clog",
+			funcName: "genericFunc[...]",
+		},
+		{
+			name:     genericClosure[int]()(),
+			pkgPath:  "github.com/mewpkg/clog",
+			pkgName:  "clog",
+			funcName: "genericClosure[...].func1",
+		},
+	}
+	for _, g := range golden {
+		if got := getPkgPath(g.name); got != g.pkgPath {
+			t.Errorf("%q: package path mismatch; expected %q, got %q", g.name, g.pkgPath, got)
+		}
+		if got := getPkgName(g.name); got != g.pkgName {
+			t.Errorf("%q: package name mismatch; expected %q, got %q", g.name, g.pkgName, got)
+		}
+		if got := getFuncName(g.name); got != g.funcName {
+			t.Errorf("%q: function name mismatch; expected %q, got %q", g.name, g.funcName, got)
+		}
+	}
+}
+
+// genericCache is a generic type with a pointer receiver method.
+type genericCache[T any] struct{}
+
+// get returns the function name of the method, as reported by the Go runtime.
+func (*genericCache[T]) get() string {
+	return funcName()
+}
+
+// genericPair is a generic type with a value receiver method.
+type genericPair[K comparable, V any] struct{}
+
+// get returns the function name of the method, as reported by the Go runtime.
+func (genericPair[K, V]) get() string {
+	return funcName()
+}
+
+// genericFunc returns the function name of the generic function, as reported
+// by the Go runtime.
+func genericFunc[T any]() string {
+	return funcName()
+}
+
+// genericClosure returns a closure which returns the function name of the
+// closure, as reported by the Go runtime.
+func genericClosure[T any]() func() string {
+	return func() string {
+		return funcName()
+	}
+}
+
+// funcName returns the function name of the caller, as reported by the Go
+// runtime.
+//
+//go:noinline
+func funcName() string {
+	pc, _, _, _ := runtime.Caller(1)
+	return runtime.FuncForPC(pc).Name()
+}
+
+// BenchmarkDisabled measures the cost of disabled log calls. The "fast" path
+// returns before resolving the caller, as the log level is disabled for all
+// callers. The "slow" path resolves the caller, as a path level enables the log
+// level for other callers.
+func BenchmarkDisabled(b *testing.B) {
+	resetLevels(b)
+	SetLevel(LevelError)
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Debug("foo")
+		}
+	})
+	SetPathLevel("github.com/me/other", LevelDebug)
+	b.Run("slow", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Debug("foo")
+		}
+	})
+}
+
+// BenchmarkInfoString compares the allocations of InfoString with those of Info
+// for a single string argument.
+func BenchmarkInfoString(b *testing.B) {
+	restoreOutput(b, io.Discard)
+	msg := strings.Repeat("x", 64)
+	b.Run("Info", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Info(msg)
+		}
+	})
+	b.Run("InfoString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			InfoString(msg)
+		}
+	})
+}
+
+func TestNoColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetOutputColor(buf, true)
+	SetFileLineHyperlinks(true)
+	defer SetFileLineHyperlinks(false)
+	defer func() {
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
+		disableColor = false
+	}()
+	parseEnvNoColor("")
+	Warn("foo")
+	if got := buf.String(); !strings.Contains(got, "\x1b") {
+		t.Errorf("NO_COLOR unset; expected colored output, got %q", got)
+	}
+	buf.Reset()
+	parseEnvNoColor("1")
+	Warn("foo")
+	if got := buf.String(); strings.Contains(got, "\x1b") {
+		t.Errorf("NO_COLOR set; expected output without color or hyperlinks, got %q", got)
+	}
+}
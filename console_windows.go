@@ -0,0 +1,47 @@
+//go:build windows
+
+package clog
+
+import (
+	"os"
+	"syscall"
+)
+
+// --- [ console ] -------------------------------------------------------------
+
+// enableVirtualTerminalProcessing is the console mode flag of Windows consoles
+// which enables the interpretation of ANSI escape sequences.
+const enableVirtualTerminalProcessing = 0x0004
+
+// procSetConsoleMode is the SetConsoleMode function of the Windows API.
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// Legacy Windows consoles do not interpret ANSI escape sequences, unless
+// virtual terminal processing is enabled. Enable it for standard output and
+// standard error when attached to a console, and disable color if not
+// possible, so escape sequences are not output as literal text.
+func init() {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if !isTerminal(f) {
+			continue
+		}
+		if !enableVirtualTerminal(f) {
+			disableColor = true
+		}
+	}
+}
+
+// enableVirtualTerminal enables virtual terminal processing for the console of
+// the given file, and reports whether it succeeded.
+func enableVirtualTerminal(f *os.File) bool {
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ret, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return ret != 0
+}
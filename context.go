@@ -0,0 +1,131 @@
+package clog
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// --- [ context ] -------------------------------------------------------------
+
+// fieldsKey is the context key of structured fields stored by WithValue.
+type fieldsKey struct{}
+
+var (
+	// contextMutex is a mutex for concurrent access to contextKeys.
+	contextMutex sync.RWMutex
+	// contextKeys maps from field names to registered context keys, whose
+	// context values are rendered as structured fields.
+	contextKeys = make(map[string]any)
+)
+
+// WithValue returns a copy of ctx carrying the given key-value pair, which is
+// rendered as a structured field by context-aware log functions (e.g.
+// InfoCtx).
+//
+// Example usage:
+//
+//	ctx = clog.WithValue(ctx, "trace_id", traceID)
+//	clog.InfoCtx(ctx, "handling request") // main: handling request trace_id=...
+func WithValue(ctx context.Context, key string, val any) context.Context {
+	prev, _ := ctx.Value(fieldsKey{}).(Fields)
+	fields := make(Fields, len(prev)+1)
+	maps.Copy(fields, prev)
+	fields[key] = val
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// RegisterContextKey registers a context key whose value (if present in the
+// context) is rendered as a structured field with the given name by
+// context-aware log functions. This allows context values stored by other
+// packages (e.g. trace IDs of a tracing package) to be logged.
+func RegisterContextKey(name string, key any) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+	contextKeys[name] = key
+}
+
+// ContextFields returns the structured fields of ctx; i.e. the key-value pairs
+// stored by WithValue and the values of registered context keys.
+func ContextFields(ctx context.Context) Fields {
+	fields := make(Fields)
+	contextMutex.RLock()
+	for name, key := range contextKeys {
+		if val := ctx.Value(key); val != nil {
+			fields[name] = val
+		}
+	}
+	contextMutex.RUnlock()
+	if stored, ok := ctx.Value(fieldsKey{}).(Fields); ok {
+		maps.Copy(fields, stored)
+	}
+	return fields
+}
+
+// DebugCtx outputs the given debug message to standard error, followed by the
+// structured fields of ctx.
+func DebugCtx(ctx context.Context, args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fmt.Sprint(args...), &Entry{fields: ContextFields(ctx)})
+}
+
+// InfoCtx outputs the given info message to standard error, followed by the
+// structured fields of ctx.
+func InfoCtx(ctx context.Context, args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprint(args...), &Entry{fields: ContextFields(ctx)})
+}
+
+// WarnCtx outputs the given non-fatal warning message to standard error,
+// followed by the structured fields of ctx.
+func WarnCtx(ctx context.Context, args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, fmt.Sprint(args...), &Entry{fields: ContextFields(ctx)})
+}
+
+// ErrorCtx outputs the given error message to standard error, followed by the
+// structured fields of ctx, without terminating the application.
+func ErrorCtx(ctx context.Context, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, fmt.Sprint(args...), &Entry{fields: ContextFields(ctx)})
+}
+
+// FatalCtx outputs the given fatal error message to standard error, followed by
+// the structured fields of ctx, and terminates the application.
+func FatalCtx(ctx context.Context, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := fmt.Sprint(args...)
+	writeFatal(c, LevelError, msg, &Entry{fields: ContextFields(ctx)})
+	exit(msg)
+}
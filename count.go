@@ -0,0 +1,70 @@
+package clog
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ count-only mode ] -----------------------------------------------------
+
+var (
+	// countOnly specifies whether to count log calls rather than output them.
+	countOnly atomic.Bool
+
+	// countMutex is a mutex for concurrent access to counts.
+	countMutex sync.Mutex
+	// counts specifies the number of log calls per package path and log level
+	// in count-only mode.
+	counts = make(map[string]map[Level]int)
+)
+
+// SetCountOnly sets whether to count log calls per package and log level rather
+// than output them (default false). Enabling count-only mode resets the counts.
+//
+// In count-only mode, log calls of all log levels are counted regardless of
+// path levels and the global log level, and no output is produced. Fatal log
+// functions still terminate the application (or panic or return, as specified
+// by SetFatalBehavior). This helps estimate the log volume of packages before
+// enabling verbose log levels.
+//
+// Example usage:
+//
+//	clog.SetCountOnly(true)
+//	runWorkload()
+//	for pkg, levels := range clog.Counts() {
+//		fmt.Println(pkg, levels[clog.LevelDebug])
+//	}
+func SetCountOnly(enable bool) {
+	if enable {
+		countMutex.Lock()
+		counts = make(map[string]map[Level]int)
+		countMutex.Unlock()
+	}
+	countOnly.Store(enable)
+}
+
+// Counts returns a copy of the number of log calls per package path and log
+// level counted in count-only mode.
+func Counts() map[string]map[Level]int {
+	countMutex.Lock()
+	defer countMutex.Unlock()
+	m := make(map[string]map[Level]int, len(counts))
+	for pkgPath, levels := range counts {
+		m[pkgPath] = maps.Clone(levels)
+	}
+	return m
+}
+
+// countCall counts a log call of the given log level from the given package
+// path.
+func countCall(pkgPath string, level Level) {
+	countMutex.Lock()
+	defer countMutex.Unlock()
+	levels, ok := counts[pkgPath]
+	if !ok {
+		levels = make(map[Level]int)
+		counts[pkgPath] = levels
+	}
+	levels[level]++
+}
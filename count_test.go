@@ -0,0 +1,29 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCountOnlyFatal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetCountOnly(true)
+	defer SetCountOnly(false)
+	codes := catchExit(t)
+	Info("foo")
+	Fatal("bar")
+	Log(LevelFatal, "baz")
+	Must(0, errors.New("qux"))
+	if got := buf.String(); len(got) != 0 {
+		t.Errorf("expected no output, got %q", got)
+	}
+	if got := len(*codes); got != 3 {
+		t.Errorf("expected 3 exits, got %d", got)
+	}
+	levels := Counts()["github.com/mewpkg/clog"]
+	if levels[LevelInfo] != 1 || levels[LevelError] != 2 || levels[LevelFatal] != 1 {
+		t.Errorf("unexpected counts %v", levels)
+	}
+}
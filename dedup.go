@@ -0,0 +1,122 @@
+package clog
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- [ deduplication ] -------------------------------------------------------
+
+var (
+	// dedupWindow specifies the time window within which identical log messages
+	// are collapsed; or 0 if deduplication is disabled.
+	dedupWindow time.Duration
+	// dedupLast tracks the last output log message, for deduplication.
+	dedupLast struct {
+		// Identity of the log message (as returned by dedupKey).
+		key string
+		// Log level of the log message.
+		level Level
+		// Package name of the caller of the log message.
+		pkg string
+		// Time when the log message was output.
+		time time.Time
+		// Number of suppressed repetitions of the log message.
+		count int
+	}
+	// dedupTimer flushes the repetition notice once the time window of the last
+	// log message expires.
+	dedupTimer *time.Timer
+)
+
+// SetDedup sets the time window within which consecutive identical log messages
+// are collapsed (default 0, which disables deduplication).
+//
+// When a log message is repeated within the time window of its first output,
+// the repetition is suppressed and counted. Once a different log message is
+// output or the time window expires, a "(last message repeated N times)" notice
+// is output in place of the suppressed repetitions.
+func SetDedup(window time.Duration) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	flushDedup()
+	dedupWindow = window
+}
+
+// dedupSuppress reports whether to suppress the given log message, as it
+// repeats the last log message within the time window of deduplication.
+// Pending repetition notices of previous log messages are flushed.
+//
+// Note, outputMutex must be held by the caller.
+func dedupSuppress(r *record) bool {
+	key := dedupKey(r)
+	t := r.time
+	if key == dedupLast.key && r.level == dedupLast.level && t.Sub(dedupLast.time) < dedupWindow {
+		dedupLast.count++
+		if dedupLast.count == 1 {
+			remaining := dedupWindow - t.Sub(dedupLast.time)
+			var timer *time.Timer
+			timer = time.AfterFunc(remaining, func() {
+				outputMutex.Lock()
+				defer outputMutex.Unlock()
+				// the timer may fire while a new log message holds outputMutex,
+				// in which case the timer has been replaced (or stopped) and the
+				// deduplication state belongs to the new log message.
+				if dedupTimer != timer {
+					return
+				}
+				flushDedup()
+			})
+			dedupTimer = timer
+		}
+		return true
+	}
+	flushDedup()
+	dedupLast.key = key
+	dedupLast.level = r.level
+	dedupLast.pkg = getPkgName(r.caller.name)
+	dedupLast.time = t
+	return false
+}
+
+// flushDedup outputs the repetition notice of the last log message (if any),
+// and resets the deduplication state.
+//
+// Note, outputMutex must be held by the caller.
+func flushDedup() {
+	if dedupTimer != nil {
+		dedupTimer.Stop()
+		dedupTimer = nil
+	}
+	if dedupLast.count > 0 {
+		notice := dedupNotice(fmt.Sprintf("(last message repeated %d times)", dedupLast.count))
+		sinks, _ := levelOutput(dedupLast.level)
+		for _, s := range sinks {
+			output(s.w, notice)
+		}
+	}
+	dedupLast.key = ""
+	dedupLast.level = 0
+	dedupLast.pkg = ""
+	dedupLast.count = 0
+}
+
+// dedupNotice returns the log line of the given repetition notice, formatted
+// using the formatter (if any) as a log message of the last log message's log
+// level and package.
+//
+// Note, outputMutex must be held by the caller.
+func dedupNotice(msg string) []byte {
+	if formatter != nil {
+		if data, err := formatter.Format(dedupLast.level, dedupLast.pkg, msg, nil); err == nil {
+			return append(data, '\n')
+		}
+	}
+	return []byte(msg + "\n")
+}
+
+// dedupKey returns the identity of the given log message, disregarding its
+// time.
+func dedupKey(r *record) string {
+	return fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%s", r.level, r.caller.name, r.tag, r.msg, formatFields(r.fields, noColor, noColor))
+}
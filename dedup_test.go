@@ -0,0 +1,74 @@
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetDedup(time.Hour)
+	defer SetDedup(0)
+	Info("foo")
+	Info("foo")
+	Info("foo")
+	Info("bar")
+	SetDedup(0)
+	want := "clog: foo\n(last message repeated 2 times)\nclog: bar\n"
+	if got := buf.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDedupStaleTimer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetDedup(10 * time.Millisecond)
+	defer SetDedup(0)
+	Info("foo")
+	Info("foo")
+	// hold outputMutex while the timer of the repeated log message fires, and
+	// output a new log message before the timer gets hold of outputMutex.
+	outputMutex.Lock()
+	time.Sleep(50 * time.Millisecond)
+	r := &record{caller: getCaller(), level: LevelInfo, time: now(), msg: "bar"}
+	if dedupSuppress(r) {
+		t.Errorf("expected new log message not to be suppressed")
+	}
+	outputMutex.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	outputMutex.Lock()
+	key := dedupLast.key
+	outputMutex.Unlock()
+	if want := dedupKey(r); key != want {
+		t.Errorf("deduplication state of new log message reset by stale timer; expected key %q, got %q", want, key)
+	}
+}
+
+func TestDedupFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetFormatter(JSONFormatter{})
+	defer SetFormatter(nil)
+	SetDedup(time.Hour)
+	defer SetDedup(0)
+	Info("foo")
+	Info("foo")
+	SetDedup(0)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("invalid JSON log line %q", line)
+		}
+	}
+	if !strings.Contains(lines[1], `"pkg":"clog","msg":"(last message repeated 1 times)"`) {
+		t.Errorf("unexpected repetition notice %q", lines[1])
+	}
+}
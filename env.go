@@ -0,0 +1,80 @@
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// --- [ environment variables ] -----------------------------------------------
+
+// Log levels may be set without code changes using environment variables, which
+// are parsed at program initialization:
+//
+//	CLOG_LEVEL   global log level (e.g. "info").
+//	CLOG_LEVELS  comma-separated path=level pairs of path levels (e.g.
+//	             "github.com/user/app=debug,github.com/user/app/db=warn").
+//	NO_COLOR     disable color, including file:line hyperlinks, if set to a
+//	             non-empty value (see https://no-color.org); SetColor(true)
+//	             overrides it.
+//
+// Malformed entries are reported to standard error and skipped.
+func init() {
+	if name, ok := os.LookupEnv("CLOG_LEVEL"); ok {
+		if err := parseEnvLevel(name); err != nil {
+			fmt.Fprintf(os.Stderr, "clog: unable to parse CLOG_LEVEL; %v\n", err)
+		}
+	}
+	if levels, ok := os.LookupEnv("CLOG_LEVELS"); ok {
+		for _, entry := range strings.Split(levels, ",") {
+			if err := parseEnvPathLevel(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "clog: unable to parse CLOG_LEVELS entry %q; %v\n", entry, err)
+			}
+		}
+	}
+	parseEnvNoColor(os.Getenv("NO_COLOR"))
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseEnvLevel parses the given global log level and sets it as the global log
+// level.
+func parseEnvLevel(name string) error {
+	level, err := ParseLevel(strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	SetLevel(level)
+	return nil
+}
+
+// parseEnvPathLevel parses the given path=level pair and sets the path level.
+// Empty entries are ignored.
+func parseEnvPathLevel(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if len(entry) == 0 {
+		return nil
+	}
+	path, name, ok := strings.Cut(entry, "=")
+	path = strings.TrimSpace(path)
+	if !ok || len(path) == 0 {
+		return errors.New("invalid path level; expected path=level")
+	}
+	level, err := ParseLevel(strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	SetPathLevel(path, level)
+	return nil
+}
+
+// parseEnvNoColor disables color if the given NO_COLOR value is non-empty.
+func parseEnvNoColor(value string) {
+	if len(value) == 0 {
+		return
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	disableColor = true
+}
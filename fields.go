@@ -0,0 +1,417 @@
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// --- [ fields ] --------------------------------------------------------------
+
+// Fields specifies structured key-value pairs attached to a log message.
+type Fields map[string]any
+
+// Entry is a log entry carrying structured fields, which are rendered after the
+// log message as key=value pairs.
+type Entry struct {
+	// fields specifies the structured fields of the log entry.
+	fields Fields
+	// prefix specifies the tag of the log entry (e.g. "scheduler"); or empty if
+	// untagged.
+	prefix string
+}
+
+// WithFields returns a log entry carrying the given structured fields.
+//
+// Example usage:
+//
+//	clog.WithFields(clog.Fields{"user_id": 42, "req": "abc"}).Info("done")
+func WithFields(fields Fields) *Entry {
+	return &Entry{fields: maps.Clone(fields)}
+}
+
+// WithFields returns a copy of the log entry, extended with the given
+// structured fields.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	maps.Copy(merged, e.fields)
+	maps.Copy(merged, fields)
+	return &Entry{fields: merged, prefix: e.prefix}
+}
+
+// Err returns structured fields describing the given error; the "error" field
+// holds the error message, and the "error_chain" field holds the messages of
+// the errors it wraps, as unwrapped by errors.Unwrap (if any). A nil error
+// results in no fields.
+//
+// Example usage:
+//
+//	clog.WithFields(clog.Err(err)).Error("unable to load config")
+func Err(err error) Fields {
+	if err == nil {
+		return nil
+	}
+	fields := Fields{"error": err.Error()}
+	var chain []string
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+	}
+	if len(chain) > 0 {
+		fields["error_chain"] = chain
+	}
+	return fields
+}
+
+// WithPrefix returns a log entry tagged with the given prefix, which is rendered
+// in brackets between the package name prefix and the log message (e.g.
+// "main: [scheduler] started"). Log messages output using a formatter (e.g.
+// JSONFormatter) include the prefix as the "tag" field. An empty prefix leaves
+// log messages untagged.
+//
+// Example usage:
+//
+//	scheduler := clog.WithPrefix("scheduler")
+//	worker := clog.WithPrefix("worker")
+//	scheduler.Info("started")
+//	worker.Info("started")
+func WithPrefix(prefix string) *Entry {
+	return &Entry{prefix: prefix}
+}
+
+// WithPrefix returns a copy of the log entry, tagged with the given prefix.
+func (e *Entry) WithPrefix(prefix string) *Entry {
+	return &Entry{fields: e.fields, prefix: prefix}
+}
+
+// Debug outputs the given debug message to standard error.
+func (e *Entry) Debug(args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fmt.Sprint(args...), e)
+}
+
+// Debugf outputs the given debug message to standard error.
+func (e *Entry) Debugf(format string, args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, fmt.Sprintf(format, args...), e)
+}
+
+// Debugln outputs the given debug message to standard error.
+func (e *Entry) Debugln(args ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, sprintln(args...), e)
+}
+
+// Info outputs the given info message to standard error.
+func (e *Entry) Info(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprint(args...), e)
+}
+
+// Infof outputs the given info message to standard error.
+func (e *Entry) Infof(format string, args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, fmt.Sprintf(format, args...), e)
+}
+
+// Infoln outputs the given info message to standard error.
+func (e *Entry) Infoln(args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, sprintln(args...), e)
+}
+
+// Warn outputs the given non-fatal warning message to standard error.
+func (e *Entry) Warn(args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, fmt.Sprint(args...), e)
+}
+
+// Warnf outputs the given non-fatal warning message to standard error.
+func (e *Entry) Warnf(format string, args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, fmt.Sprintf(format, args...), e)
+}
+
+// Warnln outputs the given non-fatal warning message to standard error.
+func (e *Entry) Warnln(args ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, sprintln(args...), e)
+}
+
+// Error outputs the given error message to standard error, without terminating
+// the application.
+func (e *Entry) Error(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, fmt.Sprint(args...), e)
+}
+
+// Errorf outputs the given error message to standard error, without
+// terminating the application.
+func (e *Entry) Errorf(format string, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, fmt.Sprintf(format, args...), e)
+}
+
+// Errorln outputs the given error message to standard error, without
+// terminating the application.
+func (e *Entry) Errorln(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, sprintln(args...), e)
+}
+
+// Fatal outputs the given fatal error message to standard error and terminates
+// the application.
+func (e *Entry) Fatal(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := fmt.Sprint(args...)
+	writeFatal(c, LevelError, msg, e)
+	exit(msg)
+}
+
+// Fatalf outputs the given fatal error message to standard error and terminates
+// the application.
+func (e *Entry) Fatalf(format string, args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	writeFatal(c, LevelError, msg, e)
+	exit(msg)
+}
+
+// Fatalln outputs the given fatal error message to standard error and
+// terminates the application.
+func (e *Entry) Fatalln(args ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	msg := sprintln(args...)
+	writeFatal(c, LevelError, msg, e)
+	exit(msg)
+}
+
+// --- [ key-value pairs ] -----------------------------------------------------
+
+// Debugw outputs the given debug message to standard error, followed by the
+// given alternating key-value pairs as structured fields.
+//
+// Example usage:
+//
+//	clog.Debugw("user logged in", "user_id", 42, "ip", addr)
+func Debugw(msg string, kvs ...any) {
+	if disabled(LevelDebug) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelDebug) {
+		return
+	}
+	write(c, LevelDebug, msg, &Entry{fields: kvFields(kvs)})
+}
+
+// Infow outputs the given info message to standard error, followed by the given
+// alternating key-value pairs as structured fields.
+func Infow(msg string, kvs ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, msg, &Entry{fields: kvFields(kvs)})
+}
+
+// Warnw outputs the given non-fatal warning message to standard error, followed
+// by the given alternating key-value pairs as structured fields.
+func Warnw(msg string, kvs ...any) {
+	if disabled(LevelWarn) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelWarn) {
+		return
+	}
+	write(c, LevelWarn, msg, &Entry{fields: kvFields(kvs)})
+}
+
+// Errorw outputs the given error message to standard error, followed by the
+// given alternating key-value pairs as structured fields, without terminating
+// the application.
+func Errorw(msg string, kvs ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelError) {
+		return
+	}
+	write(c, LevelError, msg, &Entry{fields: kvFields(kvs)})
+}
+
+// Fatalw outputs the given fatal error message to standard error, followed by
+// the given alternating key-value pairs as structured fields, and terminates
+// the application.
+func Fatalw(msg string, kvs ...any) {
+	if disabled(LevelError) {
+		return
+	}
+	c := getCaller()
+	if skipFatal(c, LevelError) {
+		return
+	}
+	writeFatal(c, LevelError, msg, &Entry{fields: kvFields(kvs)})
+	exit(msg)
+}
+
+// --- [ field colors ] --------------------------------------------------------
+
+var (
+	// fieldKeyColor specifies the terminal color of keys of structured fields.
+	fieldKeyColor = dim
+	// fieldValColor specifies the terminal color of values of structured
+	// fields.
+	fieldValColor = noColor
+)
+
+// SetFieldColors sets the terminal colors of keys and values of structured
+// fields (default dim keys and uncolored values). A nil color function restores
+// the default color.
+//
+// Field colors are only used for coloured text output; they are ignored when
+// color is disabled or a formatter is active (see SetFormatter).
+func SetFieldColors(keyColor, valColor func(string) string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if keyColor == nil {
+		keyColor = dim
+	}
+	if valColor == nil {
+		valColor = noColor
+	}
+	fieldKeyColor, fieldValColor = keyColor, valColor
+}
+
+// ### [ Helper functions ] ####################################################
+
+// missingValue is the placeholder value of a trailing key without value in
+// alternating key-value pairs.
+const missingValue = "!MISSING_VALUE"
+
+// kvFields returns the given alternating key-value pairs as structured fields.
+// Keys are formatted using %v, and a trailing key without value is given the
+// value "!MISSING_VALUE".
+func kvFields(kvs []any) Fields {
+	fields := make(Fields, (len(kvs)+1)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		key := fmt.Sprint(kvs[i])
+		if i+1 < len(kvs) {
+			fields[key] = kvs[i+1]
+		} else {
+			fields[key] = missingValue
+		}
+	}
+	return fields
+}
+
+// formatFields returns the structured fields formatted as space-separated
+// key=value pairs, sorted by key, using the given terminal colors of keys and
+// values. Values are formatted using %v.
+func formatFields(fields Fields, keyColor, valColor func(string) string) string {
+	sb := &strings.Builder{}
+	for i, key := range slices.Sorted(maps.Keys(fields)) {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(keyColor(key + "="))
+		sb.WriteString(valColor(fmt.Sprint(fields[key])))
+	}
+	return sb.String()
+}
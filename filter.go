@@ -0,0 +1,89 @@
+package clog
+
+import (
+	"slices"
+	"sync"
+)
+
+// --- [ package allowlist ] ---------------------------------------------------
+
+var (
+	// filterMutex is a readers/writer mutex for concurrent access to
+	// onlyPackages and mutedPackages.
+	filterMutex sync.RWMutex
+	// onlyPackages specifies the package paths of callers whose log output is
+	// allowed; output of all other callers is suppressed. An empty set allows
+	// output of all callers.
+	onlyPackages = make(map[string]bool)
+	// mutedPackages specifies the package paths of muted callers.
+	mutedPackages = make(map[string]bool)
+)
+
+// SetOnlyPackages restricts log output to callers within the given package
+// paths (e.g. "github.com/user/repo/pkg"), suppressing the output of all other
+// callers regardless of log level. Log levels still apply to callers within
+// the given packages.
+//
+// Calling SetOnlyPackages without arguments disables the allowlist (default).
+func SetOnlyPackages(paths ...string) {
+	filterMutex.Lock()
+	defer filterMutex.Unlock()
+	onlyPackages = make(map[string]bool, len(paths))
+	for _, path := range paths {
+		onlyPackages[path] = true
+	}
+}
+
+// --- [ muted packages ] ------------------------------------------------------
+
+// MutePackage suppresses all log output of callers within the given package
+// path (e.g. "github.com/user/repo/pkg") and its sub-packages, regardless of
+// log level. Muting takes precedence over path levels.
+func MutePackage(path string) {
+	filterMutex.Lock()
+	defer filterMutex.Unlock()
+	mutedPackages[path] = true
+}
+
+// UnmutePackage removes the given package path from the set of muted packages.
+func UnmutePackage(path string) {
+	filterMutex.Lock()
+	defer filterMutex.Unlock()
+	delete(mutedPackages, path)
+}
+
+// ListMutedPackages returns the muted package paths in sorted order.
+func ListMutedPackages() []string {
+	filterMutex.RLock()
+	defer filterMutex.RUnlock()
+	var paths []string
+	for path := range mutedPackages {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// ### [ Helper functions ] ####################################################
+
+// filtered reports whether to suppress log output of callers within the given
+// package path, either as the package is outside of the package allowlist or
+// as the package (or a parent) is muted.
+func filtered(pkgPath string) bool {
+	filterMutex.RLock()
+	defer filterMutex.RUnlock()
+	if len(onlyPackages) > 0 && !onlyPackages[pkgPath] {
+		return true
+	}
+	if len(mutedPackages) == 0 {
+		return false
+	}
+	for path := pkgPath; ; path = parentDir(path) {
+		if mutedPackages[path] {
+			return true
+		}
+		if parentDir(path) == path {
+			return false
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package clog
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// flushWriter is an output writer which records calls to Flush.
+type flushWriter struct {
+	bytes.Buffer
+	// Number of calls to Flush.
+	flushes int
+}
+
+func (fw *flushWriter) Flush() error {
+	fw.flushes++
+	return nil
+}
+
+// syncWriter is an output writer which records calls to Sync.
+type syncWriter struct {
+	bytes.Buffer
+	// Number of calls to Sync.
+	syncs int
+}
+
+func (sw *syncWriter) Sync() error {
+	sw.syncs++
+	return nil
+}
+
+func TestFatalFlush(t *testing.T) {
+	fw := &flushWriter{}
+	sw := &syncWriter{}
+	restoreOutput(t, &fw.Buffer)
+	SetErrorOutput(fw)
+	AddErrorOutput(sw)
+	codes := catchExit(t)
+	Fatal("foo")
+	if fw.flushes != 1 {
+		t.Errorf("expected 1 call to Flush, got %d", fw.flushes)
+	}
+	if sw.syncs != 1 {
+		t.Errorf("expected 1 call to Sync, got %d", sw.syncs)
+	}
+	if len(*codes) != 1 {
+		t.Errorf("expected 1 exit, got %d", len(*codes))
+	}
+}
+
+func TestFatalFlushBufio(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	bw := bufio.NewWriterSize(buf, 4096)
+	SetErrorOutput(bw)
+	SetErrorPrefix(false)
+	defer SetErrorPrefix(true)
+	var got string
+	SetExitFunc(func(code int) {
+		// record output at the time of exit.
+		got = buf.String()
+	})
+	defer SetExitFunc(os.Exit)
+	Fatal("foo")
+	if want := "foo\n"; got != want {
+		t.Errorf("expected buffered output %q to be flushed before exit, got %q", want, got)
+	}
+}
+
+func TestFatalStackTrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetErrorPrefix(false)
+	defer SetErrorPrefix(true)
+	SetInfoPrefix(false)
+	defer SetInfoPrefix(true)
+	SetFatalStackTrace(true)
+	defer SetFatalStackTrace(false)
+	catchExit(t)
+	// output an info message from another goroutine once the fatal error
+	// message has been output, before the application terminates.
+	hookMutex.Lock()
+	prevHooks := hooks
+	hooks = []func(level Level, pkg, msg string){func(level Level, pkg, msg string) {
+		if msg != "foo" {
+			return
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Info("bar")
+		}()
+		<-done
+	}}
+	hookMutex.Unlock()
+	defer func() {
+		hookMutex.Lock()
+		defer hookMutex.Unlock()
+		hooks = prevHooks
+	}()
+	Fatal("foo")
+	got := buf.String()
+	if !strings.HasPrefix(got, "foo\ngoroutine ") {
+		t.Errorf("expected stack trace beneath fatal error message, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\nbar\n") {
+		t.Errorf("expected info message after stack trace, got %q", got)
+	}
+}
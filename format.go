@@ -0,0 +1,245 @@
+package clog
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- [ formatter ] -----------------------------------------------------------
+
+// Formatter formats log messages.
+type Formatter interface {
+	// Format returns the log message of the given log level, emitted from the
+	// given package and carrying the given structured fields, formatted as a
+	// single line without trailing newline.
+	Format(level Level, pkg, msg string, fields Fields) ([]byte, error)
+}
+
+var (
+	// formatter specifies the formatter used for log messages; or nil to use the
+	// default coloured text output.
+	formatter Formatter
+	// formatterAutoSelected specifies whether the formatter was selected
+	// automatically (see SetAutoFormat), rather than set using SetFormatter.
+	formatterAutoSelected bool
+)
+
+// SetFormatter sets the formatter used for log messages. A nil formatter
+// restores the default coloured text output.
+//
+// No ANSI color codes are emitted while a formatter is active.
+func SetFormatter(f Formatter) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	formatter = f
+	formatterAutoSelected = false
+}
+
+// autoFormat specifies whether to select the formatter based on whether the
+// output writer is a terminal.
+var autoFormat bool
+
+// SetAutoFormat sets whether to select the formatter automatically based on the
+// output writer (default false); coloured text output is used if the output
+// writer is a terminal, and JSON output (see JSONFormatter) otherwise.
+//
+// The formatter is re-evaluated when the output writer is changed using
+// SetOutput. Calling SetFormatter overrides the automatically selected
+// formatter until the next re-evaluation. Disabling automatic formatter
+// selection restores the default coloured text output, unless the formatter
+// has been set using SetFormatter.
+func SetAutoFormat(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	autoFormat = enable
+	if enable {
+		selectFormatter()
+	} else if formatterAutoSelected {
+		formatter = nil
+		formatterAutoSelected = false
+	}
+}
+
+// selectFormatter selects the formatter based on whether the output writer of
+// info messages is a terminal, if automatic formatter selection is enabled.
+//
+// Note, outputMutex must be held by the caller.
+func selectFormatter() {
+	if !autoFormat {
+		return
+	}
+	if len(infoOutputs) > 0 && infoOutputs[0].useColor {
+		formatter = nil
+	} else {
+		formatter = JSONFormatter{}
+	}
+	formatterAutoSelected = true
+}
+
+// jsonCaller specifies whether to include the function name, file name and
+// line number of the caller in log messages of formatters.
+var jsonCaller bool
+
+// SetJSONCaller sets whether to include the function name, file name and line
+// number of the caller as the "func", "file" and "line" fields of log messages
+// output using a formatter (e.g. JSONFormatter); default false.
+func SetJSONCaller(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	jsonCaller = enable
+}
+
+// formatterFields returns the structured fields of the given log message passed
+// to formatters, extended with the tag, trace ID, service name, module version
+// and caller (if enabled).
+//
+// Note, outputMutex must be held by the caller.
+func formatterFields(r *record) Fields {
+	version := ""
+	if useModuleVersion && len(r.caller.name) > 0 {
+		version = moduleVersion(getPkgPath(r.caller.name))
+	}
+	if len(r.tag) == 0 && len(r.traceID) == 0 && len(serviceName) == 0 && len(version) == 0 && !jsonCaller {
+		return r.fields
+	}
+	fields := make(Fields, len(r.fields)+5)
+	maps.Copy(fields, r.fields)
+	if len(r.tag) > 0 {
+		fields["tag"] = r.tag
+	}
+	if len(r.traceID) > 0 {
+		fields["trace"] = r.traceID
+	}
+	if len(serviceName) > 0 {
+		fields["service"] = serviceName
+	}
+	if len(version) > 0 {
+		fields["version"] = version
+	}
+	if jsonCaller && len(r.caller.name) > 0 {
+		fields["func"] = getFuncName(r.caller.name)
+		fields["file"] = r.caller.file
+		fields["line"] = r.caller.line
+	}
+	return fields
+}
+
+// --- [ JSON ] ----------------------------------------------------------------
+
+// JSONFormatter formats log messages as JSON objects.
+//
+// Example output:
+//
+//	{"level":"info","pkg":"myapp","msg":"done","time":"2024-10-26T12:22:59.123Z","user_id":42}
+type JSONFormatter struct{}
+
+// Format returns the log message formatted as a JSON object. The level, pkg,
+// msg and time keys are followed by the structured fields, sorted by key.
+// Structured fields named "level", "pkg", "msg" or "time" are prefixed with
+// "fields." (e.g. "fields.level"), to avoid duplicate keys.
+func (JSONFormatter) Format(level Level, pkg, msg string, fields Fields) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("{")
+	writeJSONPair(buf, "level", level.String())
+	buf.WriteString(",")
+	writeJSONPair(buf, "pkg", pkg)
+	buf.WriteString(",")
+	writeJSONPair(buf, "msg", msg)
+	buf.WriteString(",")
+	writeJSONPair(buf, "time", now().Format(time.RFC3339Nano))
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		buf.WriteString(",")
+		writeJSONPair(buf, fieldKey(key), fields[key])
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// writeJSONPair writes the given key-value pair as JSON to buf. Errors are
+// written as their error message, and values which cannot be represented in
+// JSON (or which have no exported fields, but a string representation) are
+// formatted using %v and written as strings.
+func writeJSONPair(buf *bytes.Buffer, key string, val any) {
+	rawKey, _ := json.Marshal(key) // error is always nil for strings.
+	buf.Write(rawKey)
+	buf.WriteString(":")
+	buf.Write(jsonValue(val))
+}
+
+// jsonValue returns the given value encoded as JSON.
+func jsonValue(val any) []byte {
+	switch v := val.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		// use custom encoding of value.
+	case error:
+		// the JSON encoding of errors is typically "{}".
+		val = v.Error()
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		raw, _ = json.Marshal(fmt.Sprintf("%v", val))
+		return raw
+	}
+	if _, ok := val.(fmt.Stringer); ok && string(raw) == "{}" {
+		raw, _ = json.Marshal(fmt.Sprintf("%v", val))
+	}
+	return raw
+}
+
+// fieldKey returns the key of the given structured field in the output of
+// formatters; keys reserved for the log level, package name, message and time
+// of log messages are prefixed with "fields.".
+func fieldKey(key string) string {
+	switch key {
+	case "level", "pkg", "msg", "time":
+		return "fields." + key
+	default:
+		return key
+	}
+}
+
+// --- [ logfmt ] --------------------------------------------------------------
+
+// LogfmtFormatter formats log messages as logfmt key=value pairs.
+//
+// Example output:
+//
+//	level=info pkg=myapp msg="hello world" user_id=42
+type LogfmtFormatter struct{}
+
+// Format returns the log message formatted as logfmt key=value pairs. The
+// level, pkg and msg keys are followed by the structured fields, sorted by key.
+// Values containing spaces, equal signs or quotes are quoted, with embedded
+// quotes escaped. Structured fields named "level", "pkg", "msg" or "time" are
+// prefixed with "fields." (e.g. "fields.level"), to avoid duplicate keys.
+func (LogfmtFormatter) Format(level Level, pkg, msg string, fields Fields) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writeLogfmtPair(buf, "level", level.String())
+	buf.WriteString(" ")
+	writeLogfmtPair(buf, "pkg", pkg)
+	buf.WriteString(" ")
+	writeLogfmtPair(buf, "msg", msg)
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		buf.WriteString(" ")
+		writeLogfmtPair(buf, fieldKey(key), fmt.Sprintf("%v", fields[key]))
+	}
+	return buf.Bytes(), nil
+}
+
+// writeLogfmtPair writes the given key-value pair as logfmt to buf.
+func writeLogfmtPair(buf *bytes.Buffer, key, val string) {
+	buf.WriteString(key)
+	buf.WriteString("=")
+	if len(val) == 0 || strings.ContainsAny(val, " =\"\t\r\n\\") {
+		buf.WriteString(strconv.Quote(val))
+		return
+	}
+	buf.WriteString(val)
+}
@@ -0,0 +1,135 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stringer has no exported fields, but a string representation.
+type stringer struct {
+	s string
+}
+
+func (s stringer) String() string {
+	return s.s
+}
+
+func TestJSONFormatterFields(t *testing.T) {
+	golden := []struct {
+		fields Fields
+		want   string
+	}{
+		{fields: Fields{"err": errors.New("bad")}, want: `"err":"bad"`},
+		{fields: Fields{"err": fmt.Errorf("unable to open; %w", errors.New("bad"))}, want: `"err":"unable to open; bad"`},
+		{fields: Fields{"s": stringer{s: "foo"}}, want: `"s":"foo"`},
+		{fields: Fields{"ip": net.IPv4(127, 0, 0, 1)}, want: `"ip":"127.0.0.1"`},
+		{fields: Fields{"d": time.Second}, want: `"d":1000000000`},
+		{fields: Fields{"n": 42}, want: `"n":42`},
+		{fields: Fields{"ch": make(chan int)}, want: `"ch":"0x`},
+		{fields: Fields{"level": "x"}, want: `"fields.level":"x"`},
+		{fields: Fields{"msg": "x", "time": "y"}, want: `"fields.msg":"x","fields.time":"y"`},
+	}
+	for _, g := range golden {
+		data, err := JSONFormatter{}.Format(LevelInfo, "pkg", "msg", g.fields)
+		if err != nil {
+			t.Errorf("unable to format fields %v; %v", g.fields, err)
+			continue
+		}
+		if got := string(data); !strings.Contains(got, g.want) {
+			t.Errorf("fields %v: expected output containing %s, got %s", g.fields, g.want, got)
+		}
+		if n := strings.Count(string(data), `"level":`); n != 1 {
+			t.Errorf("fields %v: expected one level key, got %d in %s", g.fields, n, data)
+		}
+	}
+}
+
+func TestLogfmtFormatterFields(t *testing.T) {
+	data, err := LogfmtFormatter{}.Format(LevelInfo, "pkg", "msg", Fields{"level": "x", "err": errors.New("bad")})
+	if err != nil {
+		t.Fatalf("unable to format fields; %v", err)
+	}
+	want := "level=info pkg=pkg msg=msg err=bad fields.level=x"
+	if got := string(data); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestAutoFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	defer SetFormatter(nil)
+	defer SetAutoFormat(false)
+	// terminal output.
+	SetOutputColor(buf, true)
+	SetAutoFormat(true)
+	if got := currentFormatter(); got != nil {
+		t.Errorf("terminal output; expected no formatter, got %T", got)
+	}
+	// capturing output leaves the formatter unchanged.
+	CaptureOutput(func() { Info("foo") })
+	if got := currentFormatter(); got != nil {
+		t.Errorf("after capture; expected no formatter, got %T", got)
+	}
+	// non-terminal output.
+	SetOutput(buf)
+	if _, ok := currentFormatter().(JSONFormatter); !ok {
+		t.Errorf("non-terminal output; expected JSONFormatter, got %T", currentFormatter())
+	}
+	// disabling automatic formatter selection resets the selected formatter.
+	SetAutoFormat(false)
+	if got := currentFormatter(); got != nil {
+		t.Errorf("auto format disabled; expected no formatter, got %T", got)
+	}
+	// formatters set explicitly are kept.
+	SetAutoFormat(true)
+	SetFormatter(LogfmtFormatter{})
+	SetAutoFormat(false)
+	if _, ok := currentFormatter().(LogfmtFormatter); !ok {
+		t.Errorf("auto format disabled; expected LogfmtFormatter, got %T", currentFormatter())
+	}
+}
+
+// currentFormatter returns the current formatter.
+func currentFormatter() Formatter {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return formatter
+}
+
+func TestFormatterTag(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetFormatter(JSONFormatter{})
+	defer SetFormatter(nil)
+	WithPrefix("scheduler").Info("started")
+	WithFields(Fields{"n": 1}).WithPrefix("worker").Info("started")
+	Info("started")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	for i, want := range []string{`"tag":"scheduler"`, `"tag":"worker"`} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("expected line containing %s, got %s", want, lines[i])
+		}
+	}
+	if strings.Contains(lines[2], `"tag"`) {
+		t.Errorf("expected untagged line, got %s", lines[2])
+	}
+	outputMutex.Lock()
+	fields := formatterFields(&record{tag: "worker"})
+	outputMutex.Unlock()
+	data, err := LogfmtFormatter{}.Format(LevelInfo, "pkg", "msg", fields)
+	if err != nil {
+		t.Fatalf("unable to format tag; %v", err)
+	}
+	if want := "level=info pkg=pkg msg=msg tag=worker"; string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+}
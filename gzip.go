@@ -0,0 +1,118 @@
+package clog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// --- [ gzip writer ] ---------------------------------------------------------
+
+// gzipWriter is an output writer to a gzip compressed log file.
+type gzipWriter struct {
+	// mu is a mutex for concurrent access to the log file.
+	mu sync.Mutex
+	// Path of the log file.
+	path string
+	// Log file.
+	f *os.File
+	// gzip compressor of the log file.
+	zw *gzip.Writer
+}
+
+// NewGzipWriter returns a new output writer to the given gzip compressed log
+// file. The log file is appended to if it already exists, as a new member of
+// the gzip stream. The returned writer is safe for concurrent use.
+//
+// Each write is flushed to the log file, so that the log file may be followed
+// (e.g. using "tail -f | zcat") at the cost of a lower compression ratio. Close
+// must be called to finalize the gzip stream; otherwise, the log file lacks the
+// gzip trailer, though all written log lines remain readable.
+//
+// Example usage:
+//
+//	w, err := clog.NewGzipWriter("app.log.gz")
+//	if err != nil {
+//		// handle error.
+//	}
+//	defer w.Close()
+//	clog.SetOutput(w)
+func NewGzipWriter(path string) (io.WriteCloser, error) {
+	gw := &gzipWriter{path: path}
+	if err := gw.open(); err != nil {
+		return nil, err
+	}
+	return gw, nil
+}
+
+// Write compresses p and flushes it to the log file.
+func (gw *gzipWriter) Write(p []byte) (n int, err error) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	if gw.f == nil {
+		return 0, fs.ErrClosed
+	}
+	if n, err = gw.zw.Write(p); err != nil {
+		return n, fmt.Errorf("unable to compress log line of %q; %w", gw.path, err)
+	}
+	if err := gw.zw.Flush(); err != nil {
+		return n, fmt.Errorf("unable to flush log file %q; %w", gw.path, err)
+	}
+	return n, nil
+}
+
+// Close finalizes the gzip stream and closes the log file.
+func (gw *gzipWriter) Close() error {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	if gw.f == nil {
+		return fs.ErrClosed
+	}
+	return gw.close()
+}
+
+// reopen finalizes the gzip stream, and closes and reopens the log file by
+// path; e.g. after the log file has been moved by external log rotation.
+func (gw *gzipWriter) reopen() error {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	if gw.f == nil {
+		return fs.ErrClosed
+	}
+	if err := gw.close(); err != nil {
+		return err
+	}
+	return gw.open()
+}
+
+// close finalizes the gzip stream and closes the log file.
+//
+// Note, gw.mu must be held by the caller.
+func (gw *gzipWriter) close() error {
+	zerr := gw.zw.Close()
+	ferr := gw.f.Close()
+	gw.f = nil
+	if zerr != nil {
+		return fmt.Errorf("unable to finalize gzip stream of %q; %w", gw.path, zerr)
+	}
+	if ferr != nil {
+		return fmt.Errorf("unable to close log file %q; %w", gw.path, ferr)
+	}
+	return nil
+}
+
+// open opens the log file for appending, and starts a new gzip stream.
+//
+// Note, gw.mu must be held by the caller.
+func (gw *gzipWriter) open() error {
+	f, err := os.OpenFile(gw.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open log file %q; %w", gw.path, err)
+	}
+	gw.f = f
+	gw.zw = gzip.NewWriter(f)
+	return nil
+}
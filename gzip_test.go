@@ -0,0 +1,85 @@
+package clog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGzipWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.gz")
+	w, err := NewGzipWriter(path)
+	if err != nil {
+		t.Fatalf("unable to create gzip writer; %v", err)
+	}
+	gw := w.(*gzipWriter)
+	// write log lines while the log file is moved and reopened, as done by
+	// external log rotation.
+	stop := make(chan struct{})
+	written := make(chan int)
+	errs := make(chan error, 1)
+	go func() {
+		n := 0
+		defer func() { written <- n }()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := w.Write([]byte(fmt.Sprintf("line %d\n", n))); err != nil {
+				errs <- err
+				return
+			}
+			n++
+		}
+	}()
+	var paths []string
+	for i := 0; i < 100; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if err := os.Rename(path, rotated); err != nil {
+			t.Fatalf("unable to move log file; %v", err)
+		}
+		paths = append(paths, rotated)
+		if err := gw.reopen(); err != nil {
+			t.Fatalf("unable to reopen log file; %v", err)
+		}
+	}
+	close(stop)
+	n := <-written
+	select {
+	case err := <-errs:
+		t.Errorf("unable to write log line during reopen; %v", err)
+	default:
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unable to close gzip writer; %v", err)
+	}
+	paths = append(paths, path)
+	lines := 0
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("unable to open log file; %v", err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			t.Fatalf("unable to read gzip stream of %q; %v", path, err)
+		}
+		data, err := io.ReadAll(zr)
+		f.Close()
+		if err != nil {
+			t.Fatalf("unable to decompress %q; %v", path, err)
+		}
+		lines += strings.Count(string(data), "\n")
+	}
+	if lines != n {
+		t.Errorf("expected %d log lines, got %d", n, lines)
+	}
+}
@@ -0,0 +1,47 @@
+package clog
+
+import (
+	"sync"
+)
+
+// --- [ hooks ] ---------------------------------------------------------------
+
+var (
+	// hookMutex is a mutex for concurrent access to hooks.
+	hookMutex sync.RWMutex
+	// hooks specifies the registered hooks, in registration order.
+	hooks []func(level Level, pkg, msg string)
+)
+
+// AddHook registers a hook which is invoked for every log message which is not
+// filtered by log levels, with the log level, package name and message of the
+// log message. Hooks are invoked in registration order, after the log message
+// has been output, without holding any locks of clog.
+//
+// Note, hooks must not call log functions of clog, to avoid infinite
+// recursion.
+//
+// Example usage:
+//
+//	clog.AddHook(func(level clog.Level, pkg, msg string) {
+//		logLines.WithLabelValues(level.String()).Inc()
+//	})
+func AddHook(fn func(level Level, pkg, msg string)) {
+	hookMutex.Lock()
+	defer hookMutex.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// runHooks invokes the registered hooks for the given log message.
+func runHooks(r *record) {
+	hookMutex.RLock()
+	hs := hooks
+	hookMutex.RUnlock()
+	if len(hs) == 0 {
+		return
+	}
+	pkgName := getPkgName(r.caller.name)
+	for _, hook := range hs {
+		hook(r.level, pkgName, r.msg)
+	}
+}
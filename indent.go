@@ -0,0 +1,87 @@
+package clog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ indentation ] ---------------------------------------------------------
+
+// Indentation depths are tracked per goroutine, keyed by the goroutine ID of the
+// caller (as parsed from its stack trace, see goroutineID). Go provides no
+// goroutine-local storage, so the depth of a goroutine which exits while
+// indented is never released; always pair Indent with Unindent (e.g. using
+// WithIndent).
+
+var (
+	// indentMutex is a mutex for concurrent access to indentDepths.
+	indentMutex sync.Mutex
+	// indentDepths specifies the indentation depth of goroutines, keyed by
+	// goroutine ID.
+	indentDepths = make(map[uint64]int)
+	// indentCount specifies the number of goroutines with a non-zero
+	// indentation depth; used to skip goroutine ID lookups when no goroutine
+	// is indented.
+	indentCount atomic.Int64
+)
+
+// Indent increases the indentation depth of log messages of the calling
+// goroutine by one. Each level of indentation adds two spaces after the prefix
+// of log messages.
+func Indent() {
+	id := goroutineID()
+	indentMutex.Lock()
+	defer indentMutex.Unlock()
+	indentDepths[id]++
+	indentCount.Store(int64(len(indentDepths)))
+}
+
+// Unindent decreases the indentation depth of log messages of the calling
+// goroutine by one.
+func Unindent() {
+	id := goroutineID()
+	indentMutex.Lock()
+	defer indentMutex.Unlock()
+	if indentDepths[id] <= 1 {
+		delete(indentDepths, id)
+	} else {
+		indentDepths[id]--
+	}
+	indentCount.Store(int64(len(indentDepths)))
+}
+
+// WithIndent increases the indentation depth of log messages of the calling
+// goroutine by one, and returns a function which restores it.
+//
+// Example usage:
+//
+//	func walk(n *Node) {
+//		clog.Debugf("visiting %v", n)
+//		defer clog.WithIndent()()
+//		for _, child := range n.Children {
+//			walk(child)
+//		}
+//	}
+func WithIndent() func() {
+	Indent()
+	return Unindent
+}
+
+// ### [ Helper functions ] ####################################################
+
+// indentDepth returns the indentation depth of the calling goroutine.
+func indentDepth() int {
+	if indentCount.Load() == 0 {
+		return 0
+	}
+	id := goroutineID()
+	indentMutex.Lock()
+	defer indentMutex.Unlock()
+	return indentDepths[id]
+}
+
+// getIndent returns the indentation of the given depth.
+func getIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
@@ -0,0 +1,97 @@
+package clog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// --- [ custom log levels ] ---------------------------------------------------
+
+var (
+	// customLevelMutex is a readers/writer mutex for concurrent access to
+	// customLevels.
+	customLevelMutex sync.RWMutex
+	// customLevels specifies the names of custom log levels.
+	customLevels = make(map[Level]string)
+)
+
+// RegisterLevel registers a custom log level with the given value, name and
+// terminal color of prefixes (or nil to use the default color of the log
+// level). The name is used by Level.String and ParseLevel.
+//
+// Custom log levels are output using the output writers and prefix settings of
+// the closest common log level below (e.g. error messages for a log level
+// between LevelError and LevelFatal). Use LogLevel to output messages of custom
+// log levels.
+//
+// Example usage:
+//
+//	const LevelCritical = clog.LevelError + 2
+//
+//	if err := clog.RegisterLevel(LevelCritical, "critical", term.MagentaBold); err != nil {
+//		// handle error.
+//	}
+//	clog.LogLevel(LevelCritical, "disk almost full")
+func RegisterLevel(value Level, name string, color func(string) string) error {
+	if len(name) == 0 || strings.ContainsAny(name, "+- \t") {
+		return fmt.Errorf("invalid name %q of custom log level", name)
+	}
+	if _, err := commonLevel(name); err == nil {
+		return fmt.Errorf("invalid name %q of custom log level; name of common log level", name)
+	}
+	switch value {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal:
+		return fmt.Errorf("invalid value %d of custom log level %q; value of common log level %v", value, name, value)
+	}
+	customLevelMutex.Lock()
+	for level, other := range customLevels {
+		if level != value && strings.EqualFold(other, name) {
+			customLevelMutex.Unlock()
+			return fmt.Errorf("invalid name %q of custom log level; already registered", name)
+		}
+	}
+	customLevels[value] = name
+	customLevelMutex.Unlock()
+	SetLevelColor(value, color)
+	return nil
+}
+
+// LogLevel outputs the given message of the given log level (e.g. a custom log
+// level), using the output writers, prefix and color of the log level. Unlike
+// Log, LogLevel never terminates the application, so custom log levels may be
+// used above LevelFatal.
+func LogLevel(level Level, args ...any) {
+	if disabled(level) {
+		return
+	}
+	c := getCaller()
+	if skip(c, level) {
+		return
+	}
+	write(c, level, fmt.Sprint(args...), nil)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// customLevelName returns the name of the given custom log level, and a
+// boolean indicating whether the log level is registered.
+func customLevelName(level Level) (string, bool) {
+	customLevelMutex.RLock()
+	defer customLevelMutex.RUnlock()
+	name, ok := customLevels[level]
+	return name, ok
+}
+
+// customLevelValue returns the custom log level with the given case-insensitive
+// name, and a boolean indicating whether the log level is registered.
+func customLevelValue(name string) (Level, bool) {
+	customLevelMutex.RLock()
+	defer customLevelMutex.RUnlock()
+	for level, other := range customLevels {
+		if strings.EqualFold(other, name) {
+			return level, true
+		}
+	}
+	return 0, false
+}
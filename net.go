@@ -0,0 +1,273 @@
+package clog
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- [ network writer ] ------------------------------------------------------
+
+const (
+	// netDialTimeout specifies the timeout of connecting to the remote address
+	// of network writers.
+	netDialTimeout = time.Second
+	// netWriteTimeout specifies the timeout of writing a log line to the
+	// connection of network writers.
+	netWriteTimeout = 5 * time.Second
+	// netRetryInterval specifies the minimum interval between reconnection
+	// attempts of network writers.
+	netRetryInterval = time.Second
+)
+
+// netBufferSize specifies the maximum number of log lines buffered by network
+// writers pending output; or 0 if log lines are dropped while disconnected.
+var netBufferSize atomic.Int64
+
+func init() {
+	netBufferSize.Store(1024)
+}
+
+// SetNetBufferSize sets the maximum number of log lines buffered by each
+// network writer pending output (default 1024); e.g. while disconnected. When
+// the buffer is full, the oldest buffered log line is dropped. A size of 0
+// drops all log lines written while disconnected.
+func SetNetBufferSize(n int) {
+	netBufferSize.Store(int64(max(n, 0)))
+}
+
+// netWriter is an output writer to a network connection.
+type netWriter struct {
+	// Network of the remote address (e.g. "tcp").
+	network string
+	// Remote address.
+	addr string
+
+	// mu is a mutex for concurrent access to buf, connected and closed.
+	mu sync.Mutex
+	// Log lines pending output, in write order.
+	buf [][]byte
+	// connected specifies whether the connection is established.
+	connected bool
+	// closed specifies whether the writer has been closed.
+	closed bool
+
+	// wake signals the background goroutine that log lines are pending.
+	wake chan struct{}
+	// stop is closed by Close to stop the background goroutine.
+	stop chan struct{}
+	// done is closed once the background goroutine has stopped.
+	done chan struct{}
+
+	// Connection; or nil if disconnected. Only accessed by the background
+	// goroutine.
+	conn net.Conn
+	// Time of the last failed connection attempt. Only accessed by the
+	// background goroutine.
+	lastDial time.Time
+	// Error of closing the connection, set by the background goroutine before
+	// it stops.
+	closeErr error
+}
+
+// NewNetWriter returns a new output writer to the given network address (e.g.
+// "tcp", "logs.example.com:5140"), writing each log line to the connection.
+// The returned writer is safe for concurrent use.
+//
+// The address is dialed once by NewNetWriter, which returns an error if the
+// connection fails. Write only buffers the log line; a background goroutine of
+// the writer writes buffered log lines to the connection, so log calls are
+// never stalled by network I/O. Should a write fail, the connection is closed
+// and reconnection is attempted by the background goroutine, at most once per
+// second.
+//
+// Log lines are buffered in memory while pending output (see
+// SetNetBufferSize) and written in order once reconnected. When the buffer is
+// full, the oldest buffered log line is dropped and the error is passed to the
+// write error handler (see SetWriteErrorHandler); either as returned by Write,
+// or directly if dropped by the background goroutine. Close writes pending log
+// lines before closing the connection; log lines still buffered are lost if the
+// connection cannot be restored. Note, a broken TCP connection is typically
+// only detected by a later write, so log lines written shortly before an outage
+// is detected may be lost.
+//
+// Example usage:
+//
+//	w, err := clog.NewNetWriter("tcp", "logs.example.com:5140")
+//	if err != nil {
+//		// handle error.
+//	}
+//	defer w.Close()
+//	clog.SetOutput(io.MultiWriter(os.Stderr, w))
+func NewNetWriter(network, addr string) (io.WriteCloser, error) {
+	conn, err := net.DialTimeout(network, addr, netDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s address %q; %w", network, addr, err)
+	}
+	nw := &netWriter{
+		network:   network,
+		addr:      addr,
+		connected: true,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		conn:      conn,
+	}
+	go nw.run()
+	return nw, nil
+}
+
+// Write buffers p for output to the connection by the background goroutine.
+func (nw *netWriter) Write(p []byte) (n int, err error) {
+	nw.mu.Lock()
+	if nw.closed {
+		nw.mu.Unlock()
+		return 0, fs.ErrClosed
+	}
+	nw.buf = append(nw.buf, slices.Clone(p))
+	dropped := nw.trimBuf()
+	nw.mu.Unlock()
+	select {
+	case nw.wake <- struct{}{}:
+	default:
+		// background goroutine already signaled.
+	}
+	if dropped > 0 {
+		return len(p), nw.dropErr(dropped)
+	}
+	return len(p), nil
+}
+
+// Close writes pending log lines (if connected), closes the connection and
+// stops the background goroutine.
+func (nw *netWriter) Close() error {
+	nw.mu.Lock()
+	if nw.closed {
+		nw.mu.Unlock()
+		return fs.ErrClosed
+	}
+	nw.closed = true
+	nw.mu.Unlock()
+	close(nw.stop)
+	<-nw.done
+	if nw.closeErr != nil {
+		return fmt.Errorf("unable to close connection to %s address %q; %w", nw.network, nw.addr, nw.closeErr)
+	}
+	return nil
+}
+
+// run writes buffered log lines to the connection until the writer is closed,
+// reconnecting as needed.
+func (nw *netWriter) run() {
+	defer close(nw.done)
+	for {
+		nw.mu.Lock()
+		lines, closed := nw.buf, nw.closed
+		nw.buf = nil
+		nw.mu.Unlock()
+		pending := false
+		if len(lines) > 0 {
+			pending = !nw.send(lines)
+		}
+		if closed {
+			if nw.conn != nil {
+				nw.closeErr = nw.conn.Close()
+				nw.conn = nil
+			}
+			return
+		}
+		// retry pending log lines once the retry interval has elapsed.
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(netRetryInterval - time.Since(nw.lastDial))
+		}
+		select {
+		case <-nw.wake:
+		case <-nw.stop:
+		case <-retry:
+		}
+	}
+}
+
+// send writes the given log lines to the connection, in order, reconnecting if
+// disconnected. On failure, the unwritten log lines are returned to the buffer
+// and send reports false.
+//
+// Note, send must only be called by the background goroutine.
+func (nw *netWriter) send(lines [][]byte) bool {
+	if nw.conn == nil {
+		if time.Since(nw.lastDial) < netRetryInterval {
+			nw.requeue(lines)
+			return false
+		}
+		conn, err := net.DialTimeout(nw.network, nw.addr, netDialTimeout)
+		if err != nil {
+			nw.lastDial = time.Now()
+			nw.requeue(lines)
+			return false
+		}
+		nw.conn = conn
+		nw.mu.Lock()
+		nw.connected = true
+		nw.mu.Unlock()
+	}
+	for i, line := range lines {
+		nw.conn.SetWriteDeadline(time.Now().Add(netWriteTimeout))
+		n, err := nw.conn.Write(line)
+		if err != nil {
+			// retain the unwritten remainder of a partially written log line.
+			lines[i] = line[n:]
+			nw.conn.Close()
+			nw.conn = nil
+			nw.lastDial = time.Now()
+			nw.requeue(lines[i:])
+			return false
+		}
+	}
+	return true
+}
+
+// requeue returns the given unwritten log lines to the front of the buffer
+// after a connection failure, and reports dropped log lines to the write error
+// handler.
+//
+// Note, requeue must only be called by the background goroutine.
+func (nw *netWriter) requeue(lines [][]byte) {
+	nw.mu.Lock()
+	nw.connected = false
+	nw.buf = append(lines, nw.buf...)
+	dropped := nw.trimBuf()
+	nw.mu.Unlock()
+	if dropped > 0 {
+		handleWriteError(nw.dropErr(dropped))
+	}
+}
+
+// trimBuf drops the oldest buffered log lines exceeding the buffer size, and
+// returns the number of dropped log lines. While connected, at least one log
+// line is retained, so that log lines are not dropped with a buffer size of 0.
+//
+// Note, nw.mu must be held by the caller.
+func (nw *netWriter) trimBuf() int {
+	size := int(netBufferSize.Load())
+	if nw.connected {
+		size = max(size, 1)
+	}
+	dropped := len(nw.buf) - size
+	if dropped <= 0 {
+		return 0
+	}
+	clear(nw.buf[:dropped])
+	nw.buf = nw.buf[dropped:]
+	return dropped
+}
+
+// dropErr returns an error reporting the given number of dropped log lines.
+func (nw *netWriter) dropErr(dropped int) error {
+	return fmt.Errorf("unable to write to %s address %q; dropped %d buffered log line(s)", nw.network, nw.addr, dropped)
+}
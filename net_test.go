@@ -0,0 +1,44 @@
+package clog
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestNetWriter(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen; %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+	w, err := NewNetWriter("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("unable to create network writer; %v", err)
+	}
+	for _, line := range []string{"foo\n", "bar\n", "baz\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Errorf("unable to write %q; %v", line, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("unable to close network writer; %v", err)
+	}
+	if got, want := <-received, "foo\nbar\nbaz\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if _, err := w.Write([]byte("qux\n")); err == nil {
+		t.Errorf("expected error writing to closed network writer")
+	}
+}
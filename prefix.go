@@ -0,0 +1,538 @@
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mewpkg/term"
+)
+
+// --- [ sequence numbers ] ----------------------------------------------------
+
+var (
+	// useSequence specifies whether to begin prefixes with a sequence number.
+	useSequence bool
+
+	// sequence specifies the sequence number of the last output log message.
+	sequence uint64
+)
+
+// SetSequenceNumbers sets whether to begin prefixes with a monotonically
+// increasing sequence number (e.g. "#0042"); default false. Sequence numbers
+// are assigned in output order, so log lines may be totally ordered even when
+// written to multiple output writers.
+func SetSequenceNumbers(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useSequence = enable
+}
+
+// ResetSequence resets the sequence number, so that the next log message is
+// numbered 1.
+func ResetSequence() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	sequence = 0
+}
+
+// getSequence returns the given sequence number prefix, using the given
+// terminal color.
+func getSequence(seq uint64, colorFunc func(string) string) string {
+	return colorFunc(fmt.Sprintf("#%04d", seq)) + " "
+}
+
+// --- [ timestamps ] ----------------------------------------------------------
+
+var (
+	// useTimestamps specifies whether to begin prefixes with a timestamp.
+	useTimestamps bool
+
+	// timeFormat specifies the layout of timestamps, as accepted by
+	// time.Time.Format.
+	timeFormat = "15:04:05.000"
+
+	// timeUnixMillis specifies whether to format timestamps as milliseconds
+	// since the Unix epoch rather than using timeFormat.
+	timeUnixMillis bool
+
+	// timeUTC specifies whether to format timestamps in UTC.
+	timeUTC bool
+)
+
+// SetTimestamps sets whether to begin prefixes with a timestamp of the current
+// time (default false).
+func SetTimestamps(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useTimestamps = enable
+}
+
+// SetTimeFormat sets the layout of timestamps (default "15:04:05.000"), using
+// the Go reference time (see time.Layout).
+func SetTimeFormat(layout string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	timeFormat = layout
+	timeUnixMillis = false
+}
+
+// SetTimeFormatRFC3339 sets the layout of timestamps to RFC 3339 (e.g.
+// "2006-01-02T15:04:05Z07:00").
+func SetTimeFormatRFC3339() {
+	SetTimeFormat(time.RFC3339)
+}
+
+// SetTimeFormatRFC3339Nano sets the layout of timestamps to RFC 3339 with
+// nanoseconds (e.g. "2006-01-02T15:04:05.999999999Z07:00").
+func SetTimeFormatRFC3339Nano() {
+	SetTimeFormat(time.RFC3339Nano)
+}
+
+// SetTimeFormatUnixMillis sets timestamps to be formatted as the number of
+// milliseconds elapsed since the Unix epoch (e.g. "1729945379123"). Use
+// SetTimeFormat to restore a layout.
+func SetTimeFormatUnixMillis() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	timeUnixMillis = true
+}
+
+// SetTimeUTC sets whether to format timestamps in UTC rather than local time
+// (default false).
+func SetTimeUTC(utc bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	timeUTC = utc
+}
+
+// getTimestamp returns the timestamp of the given time, using the given
+// terminal color.
+//
+// Note, outputMutex must be held by the caller.
+func getTimestamp(t time.Time, colorFunc func(string) string) string {
+	if timeUnixMillis {
+		return colorFunc(strconv.FormatInt(t.UnixMilli(), 10)) + " "
+	}
+	if timeUTC {
+		t = t.UTC()
+	}
+	return colorFunc(t.Format(timeFormat)) + " "
+}
+
+// --- [ elapsed time ] --------------------------------------------------------
+
+var (
+	// useElapsed specifies whether to include the elapsed time since
+	// elapsedStart in prefixes.
+	useElapsed bool
+
+	// elapsedStart specifies the start time of elapsed times.
+	elapsedStart = now()
+)
+
+// SetElapsed sets whether to include the elapsed time in milliseconds since
+// program initialization (or since the last call to ResetElapsed) in prefixes
+// (e.g. "+123.4ms"); default false.
+func SetElapsed(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useElapsed = enable
+}
+
+// ResetElapsed resets the start time of elapsed times to the current time.
+func ResetElapsed() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	elapsedStart = now()
+}
+
+// getElapsed returns the elapsed time of the given time since elapsedStart,
+// using the given terminal color.
+//
+// Note, outputMutex must be held by the caller.
+func getElapsed(t time.Time, colorFunc func(string) string) string {
+	ms := float64(t.Sub(elapsedStart)) / float64(time.Millisecond)
+	return colorFunc(fmt.Sprintf("+%.1fms", ms)) + " "
+}
+
+// --- [ goroutine ID ] --------------------------------------------------------
+
+// useGoroutineID specifies whether to include the goroutine ID of the caller in
+// prefixes.
+var useGoroutineID atomic.Bool
+
+// SetGoroutineID sets whether to include the numeric ID of the calling goroutine
+// in prefixes (default false). This is intended for debugging concurrent code;
+// looking up the goroutine ID is slow, and is only done when enabled.
+//
+// Note, goroutine IDs are not stable across runs of the application.
+func SetGoroutineID(enable bool) {
+	useGoroutineID.Store(enable)
+}
+
+// getGoroutineID returns the goroutine ID prefix of the given goroutine ID,
+// using the given terminal color.
+func getGoroutineID(id uint64, colorFunc func(string) string) string {
+	return colorFunc(fmt.Sprintf("[%d]", id)) + " "
+}
+
+// goroutineID returns the ID of the current goroutine, as parsed from the first
+// line of its stack trace (e.g. "goroutine 12 [running]:").
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if pos := bytes.IndexByte(buf, ' '); pos != -1 {
+		buf = buf[:pos]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// --- [ package path ] --------------------------------------------------------
+
+var (
+	// useFullPackage specifies whether to use the full package path rather
+	// than the package name in prefixes.
+	useFullPackage bool
+
+	// prefixPathSegments specifies the number of trailing package path
+	// segments used in prefixes; or 0 to use the package name.
+	prefixPathSegments int
+)
+
+// SetFullPackageInPrefix sets whether to use the full package path rather than
+// the package name in prefixes (e.g. "github.com/user/repo/db:" instead of
+// "db:"); default false. This distinguishes log messages of packages with the
+// same name.
+func SetFullPackageInPrefix(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useFullPackage = enable
+}
+
+// SetPrefixPathSegments sets the number of trailing package path segments used
+// in prefixes (e.g. "repo/db:" for 2 segments, instead of "db:"); default 0,
+// which uses the package name. The full package path takes precedence if
+// enabled (see SetFullPackageInPrefix).
+func SetPrefixPathSegments(n int) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	prefixPathSegments = n
+}
+
+// getPrefixPkg returns the package name or path used in prefixes of the given
+// path-qualified function name.
+//
+// Note, outputMutex must be held by the caller.
+func getPrefixPkg(name string) string {
+	switch {
+	case useFullPackage:
+		return getPkgPath(name)
+	case prefixPathSegments > 1:
+		pkgPath := getPkgPath(name)
+		start := len(pkgPath)
+		for i := 0; i < prefixPathSegments; i++ {
+			pos := strings.LastIndex(pkgPath[:start], "/")
+			if pos == -1 {
+				return pkgPath
+			}
+			start = pos
+		}
+		return pkgPath[start+1:]
+	default:
+		return getPkgName(name)
+	}
+}
+
+// --- [ prefix padding ] ------------------------------------------------------
+
+// prefixPadding specifies the minimum width of package name prefixes; or 0 to
+// disable padding.
+var prefixPadding int
+
+// SetPrefixPadding sets the minimum width of package name prefixes (e.g.
+// "pkg: "), which are right-padded with spaces so that log messages align
+// across packages; default 0, which disables padding. The width is measured in
+// visible characters, excluding terminal color codes.
+func SetPrefixPadding(width int) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	prefixPadding = width
+}
+
+// --- [ service name ] --------------------------------------------------------
+
+// serviceName specifies the service name included in prefixes; or empty if
+// disabled.
+var serviceName string
+
+// SetServiceName sets the service name included in prefixes of all log
+// messages, before the package name (e.g. "svc=checkout main: ..."), and as the
+// "service" field of log messages output using a formatter. An empty service
+// name disables it (default).
+func SetServiceName(name string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	serviceName = name
+}
+
+// getServiceName returns the service name prefix, using the given terminal
+// color.
+//
+// Note, outputMutex must be held by the caller.
+func getServiceName(colorFunc func(string) string) string {
+	return colorFunc("svc="+serviceName) + " "
+}
+
+// --- [ function name ] -------------------------------------------------------
+
+// useFuncInPrefix specifies whether to include the function name of the caller
+// in prefixes.
+var useFuncInPrefix bool
+
+// SetFuncInPrefix sets whether to include the function name of the caller in
+// prefixes (e.g. "pkg.Func:" instead of "pkg:"); default false.
+func SetFuncInPrefix(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useFuncInPrefix = enable
+}
+
+// --- [ prefix separator ] ---------------------------------------------------
+
+var (
+	// prefixSeparator specifies the separator following the package name in
+	// prefixes.
+	prefixSeparator = ": "
+
+	// usePrefixBrackets specifies whether to enclose the package name in
+	// brackets in prefixes.
+	usePrefixBrackets bool
+)
+
+// SetPrefixSeparator sets the separator following the package name in prefixes
+// (default ": "). Trailing white space of the separator is not colored.
+//
+// For instance, the separator " | " results in prefixes such as "pkg | ".
+func SetPrefixSeparator(sep string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	prefixSeparator = sep
+}
+
+// SetPrefixBrackets sets whether to enclose the package name in brackets in
+// prefixes (default false). To output prefixes such as "[pkg] ", use together
+// with a prefix separator of " ".
+func SetPrefixBrackets(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	usePrefixBrackets = enable
+}
+
+// --- [ file name ] -----------------------------------------------------------
+
+// useShortFile specifies whether to use the base name rather than the full path
+// of source files in file:line prefixes.
+var useShortFile bool
+
+// SetShortFile sets whether to use the base name rather than the full path of
+// source files in file:line prefixes (e.g. "clog.go:123:" instead of
+// "/home/user/go/src/.../clog.go:123:"); default false.
+func SetShortFile(short bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useShortFile = short
+}
+
+// useFileLineHyperlinks specifies whether to render file:line prefixes as
+// hyperlinks to the source file.
+var useFileLineHyperlinks bool
+
+// SetFileLineHyperlinks sets whether to render file:line prefixes as clickable
+// OSC-8 terminal hyperlinks (e.g. "file:///home/user/app/main.go#12"); default
+// false. Hyperlinks are only emitted for output writers using color (see
+// SetOutputColor), and are supported by terminals such as iTerm2 and the
+// integrated terminal of VS Code.
+func SetFileLineHyperlinks(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useFileLineHyperlinks = enable
+}
+
+// hyperlink returns the given text as an OSC-8 terminal hyperlink to the given
+// line of the given source file.
+func hyperlink(text, file string, line int) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	u := &url.URL{Scheme: "file", Path: filepath.ToSlash(file), Fragment: strconv.Itoa(line)}
+	return "\x1b]8;;" + u.String() + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// --- [ colors ] --------------------------------------------------------------
+
+// levelColors specifies the terminal color of prefixes of log levels, which
+// overrides the default color of the log level.
+var levelColors = make(map[Level]func(string) string)
+
+// SetLevelColor sets the terminal color used for prefixes of the given log
+// level (e.g. term.YellowBold). A nil color function restores the default color
+// of the log level.
+func SetLevelColor(level Level, colorFunc func(string) string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if colorFunc == nil {
+		delete(levelColors, level)
+		return
+	}
+	levelColors[level] = colorFunc
+}
+
+// supportsTrueColor specifies whether the terminal supports 24-bit colors, as
+// indicated by the COLORTERM environment variable.
+var supportsTrueColor = os.Getenv("COLORTERM") == "truecolor" || os.Getenv("COLORTERM") == "24bit"
+
+// SetLevelColorRGB sets the 24-bit RGB color used for prefixes of the given log
+// level. The RGB color is only used if the terminal supports 24-bit colors (as
+// indicated by COLORTERM=truecolor); otherwise the basic color of the log level
+// is retained.
+func SetLevelColorRGB(level Level, r, g, b uint8) {
+	if !supportsTrueColor {
+		return
+	}
+	SetLevelColor(level, rgbColor(r, g, b))
+}
+
+// rgbColor returns a terminal color function rendering text in the given 24-bit
+// RGB color.
+func rgbColor(r, g, b uint8) func(string) string {
+	start := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
+	return func(text string) string {
+		return start + text + "\x1b[0m"
+	}
+}
+
+// --- [ themes ] --------------------------------------------------------------
+
+// Theme specifies the terminal colors of prefixes of debug, info, warning and
+// error messages. A nil color function uses the default color of the log
+// level.
+type Theme struct {
+	// Terminal color of debug prefixes.
+	Debug func(string) string
+	// Terminal color of info prefixes.
+	Info func(string) string
+	// Terminal color of warning prefixes.
+	Warn func(string) string
+	// Terminal color of error prefixes.
+	Error func(string) string
+}
+
+// Color themes.
+var (
+	// ThemeDefault is the default color theme.
+	ThemeDefault = Theme{
+		Debug: term.MagentaBold,
+		Info:  term.CyanBold,
+		Warn:  term.RedBold,
+		Error: term.RedBold,
+	}
+	// ThemeMonochrome is a color theme which distinguishes log levels by style
+	// rather than color.
+	ThemeMonochrome = Theme{
+		Debug: style(term.Dim),
+		Info:  style(term.Bold),
+		Warn:  style(term.Bold, term.Underline),
+		Error: style(term.Bold, term.Inverse),
+	}
+	// ThemeHighContrast is a color theme using bright foreground colors and
+	// background colors for warnings and errors.
+	ThemeHighContrast = Theme{
+		Debug: term.WhiteBold,
+		Info:  term.CyanBold,
+		Warn:  style(term.Bold, term.FgBlack, term.BgYellow),
+		Error: style(term.Bold, term.FgWhite, term.BgRed),
+	}
+)
+
+// SetTheme sets the terminal colors used for prefixes of debug, info, warning
+// and error messages (see SetLevelColor).
+//
+// Example usage:
+//
+//	clog.SetTheme(clog.ThemeHighContrast)
+func SetTheme(t Theme) {
+	SetLevelColor(LevelDebug, t.Debug)
+	SetLevelColor(LevelInfo, t.Info)
+	SetLevelColor(LevelWarn, t.Warn)
+	SetLevelColor(LevelError, t.Error)
+}
+
+// --- [ prefix format ] -------------------------------------------------------
+
+// PrefixFunc returns the prefix of a log message emitted from the given package
+// name, function name, file name and line number.
+type PrefixFunc func(pkg, funcName, file string, line int) string
+
+// prefixFormats specifies custom prefix formats of log levels, which replace
+// the default package name and file:line prefixes.
+var prefixFormats = make(map[Level]PrefixFunc)
+
+// SetPrefixFormat sets a custom prefix format of the given log level, which
+// replaces the default package name and file:line prefixes (e.g. "pkg: " or
+// "pkg: file.go:12: "). The returned prefix is rendered in the color of the log
+// level, and should include any trailing separator. A nil function restores the
+// default prefix format of the log level.
+//
+// Example usage:
+//
+//	clog.SetPrefixFormat(clog.LevelWarn, func(pkg, funcName, file string, line int) string {
+//		return fmt.Sprintf("%s:%d [%s] ", filepath.Base(file), line, pkg)
+//	})
+func SetPrefixFormat(level Level, fn PrefixFunc) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if fn == nil {
+		delete(prefixFormats, level)
+		return
+	}
+	prefixFormats[level] = fn
+}
+
+// getCustomPrefix returns the prefix of the given caller in the given custom
+// prefix format, using the given terminal color.
+func getCustomPrefix(c caller, fn PrefixFunc, colorFunc func(string) string) string {
+	return colorFunc(fn(getPkgName(c.name), getFuncName(c.name), c.file, c.line))
+}
+
+// --- [ tags ] ----------------------------------------------------------------
+
+// getTag returns the given log entry tag in brackets, using the given terminal
+// color.
+func getTag(tag string, colorFunc func(string) string) string {
+	return colorFunc("["+tag+"]") + " "
+}
+
+// ### [ Helper functions ] ####################################################
+
+// dim returns a dim text.
+func dim(text string) string {
+	return term.Color(text, term.Dim)
+}
+
+// style returns a terminal color function rendering text using the given style
+// and color codes (e.g. term.Bold).
+func style(codes ...string) func(string) string {
+	return func(text string) string {
+		return term.Color(text, codes...)
+	}
+}
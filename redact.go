@@ -0,0 +1,86 @@
+package clog
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ redaction ] -----------------------------------------------------------
+
+// redaction is a pattern of sensitive data in log messages.
+type redaction struct {
+	// Pattern of sensitive data.
+	pattern *regexp.Regexp
+	// Replacement of matches.
+	replacement string
+}
+
+var (
+	// redactionMutex serializes updates of redactions.
+	redactionMutex sync.Mutex
+	// redactions specifies the registered redactions, in registration order;
+	// or nil if none. The slice is replaced rather than modified, so it may be
+	// read without locking.
+	redactions atomic.Pointer[[]redaction]
+)
+
+// defaultRedactionReplacement is the replacement of redacted data if none is
+// given.
+const defaultRedactionReplacement = "***"
+
+// AddRedaction registers a pattern of sensitive data, whose matches in log
+// messages are replaced with the given replacement (default "***"). Within the
+// replacement, $ signs are interpreted as in regexp.Regexp.Expand; e.g. "${1}"
+// refers to the first submatch. A nil pattern is ignored.
+//
+// Redactions are applied in registration order to the formatted message of log
+// messages of all log levels, before truncation (see SetMaxMessageLength) and
+// output. Structured fields and prefixes are not redacted. Redaction is
+// disabled by default, and costs nothing until a pattern is registered.
+//
+// Example usage:
+//
+//	clog.AddRedaction(regexp.MustCompile(`password=\S+`), "password=***")
+func AddRedaction(pattern *regexp.Regexp, replacement string) {
+	if pattern == nil {
+		return
+	}
+	if replacement == "" {
+		replacement = defaultRedactionReplacement
+	}
+	redactionMutex.Lock()
+	defer redactionMutex.Unlock()
+	var rs []redaction
+	if old := redactions.Load(); old != nil {
+		rs = append(rs, *old...)
+	}
+	rs = append(rs, redaction{pattern: pattern, replacement: replacement})
+	redactions.Store(&rs)
+}
+
+// AddDefaultRedactions registers patterns of common sensitive data; bearer
+// tokens (e.g. "Bearer ***") and email addresses.
+func AddDefaultRedactions() {
+	AddRedaction(regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}"+defaultRedactionReplacement)
+	AddRedaction(regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), defaultRedactionReplacement)
+}
+
+// ClearRedactions removes all registered redactions.
+func ClearRedactions() {
+	redactionMutex.Lock()
+	defer redactionMutex.Unlock()
+	redactions.Store(nil)
+}
+
+// redact returns the given log message with the registered redactions applied.
+func redact(msg string) string {
+	rs := redactions.Load()
+	if rs == nil {
+		return msg
+	}
+	for _, r := range *rs {
+		msg = r.pattern.ReplaceAllString(msg, r.replacement)
+	}
+	return msg
+}
@@ -0,0 +1,27 @@
+package clog
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	defer ClearRedactions()
+	AddRedaction(nil, "")
+	AddRedaction(regexp.MustCompile(`password=\S+`), "password=***")
+	AddDefaultRedactions()
+	golden := []struct {
+		msg  string
+		want string
+	}{
+		{msg: "login password=hunter2 ok", want: "login password=*** ok"},
+		{msg: "Authorization: Bearer abc.def-123", want: "Authorization: Bearer ***"},
+		{msg: "user alice@example.com", want: "user ***"},
+		{msg: "nothing to redact", want: "nothing to redact"},
+	}
+	for _, g := range golden {
+		if got := redact(g.msg); got != g.want {
+			t.Errorf("%q: expected %q, got %q", g.msg, g.want, got)
+		}
+	}
+}
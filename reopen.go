@@ -0,0 +1,53 @@
+package clog
+
+import (
+	"errors"
+)
+
+// --- [ reopen ] --------------------------------------------------------------
+
+// reopener is an output writer to a log file which may be reopened by path.
+type reopener interface {
+	// reopen closes and reopens the log file by path.
+	reopen() error
+}
+
+// ReopenOutputs closes and reopens the log files of all output writers created
+// by clog (see NewRotatingWriter and NewGzipWriter); other output writers are
+// left untouched. Together with a signal handler, ReopenOutputs supports
+// external log rotation (e.g. logrotate).
+//
+// Example usage:
+//
+//	hup := make(chan os.Signal, 1)
+//	signal.Notify(hup, syscall.SIGHUP)
+//	go func() {
+//		for range hup {
+//			if err := clog.ReopenOutputs(); err != nil {
+//				clog.Error(err)
+//			}
+//		}
+//	}()
+func ReopenOutputs() error {
+	outputMutex.Lock()
+	var rs []reopener
+	seen := make(map[reopener]bool)
+	for _, sinks := range [][]sink{debugOutputs, infoOutputs, warnOutputs, errorOutputs} {
+		for _, s := range sinks {
+			if r, ok := s.w.(reopener); ok && !seen[r] {
+				seen[r] = true
+				rs = append(rs, r)
+			}
+		}
+	}
+	outputMutex.Unlock()
+	// flush log lines pending output in asynchronous mode to the old log files.
+	Flush()
+	var errs []error
+	for _, r := range rs {
+		if err := r.reopen(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,62 @@
+package clog
+
+import (
+	"strings"
+	"sync"
+)
+
+// --- [ ring buffer ] ---------------------------------------------------------
+
+// RingBuffer is an output writer which retains the most recent log lines in
+// memory. RingBuffer is safe for concurrent use.
+//
+// Example usage:
+//
+//	recent := clog.NewRingBuffer(100)
+//	clog.AddInfoOutput(recent)
+//	...
+//	for _, line := range recent.Lines() {
+//		fmt.Fprintln(w, line)
+//	}
+type RingBuffer struct {
+	// mu is a mutex for concurrent access to lines and next.
+	mu sync.Mutex
+	// Retained lines; a circular buffer of fixed capacity.
+	lines []string
+	// Index in lines of the next line to write.
+	next int
+	// Number of retained lines.
+	n int
+}
+
+// NewRingBuffer returns a new ring buffer retaining at most capacity lines.
+func NewRingBuffer(capacity int) *RingBuffer {
+	return &RingBuffer{lines: make([]string, max(capacity, 1))}
+}
+
+// Write writes the lines of p to the ring buffer, discarding the oldest lines if
+// the capacity is exceeded.
+func (rb *RingBuffer) Write(p []byte) (n int, err error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	s := strings.TrimSuffix(string(p), "\n")
+	for _, line := range strings.Split(s, "\n") {
+		rb.lines[rb.next] = line
+		rb.next = (rb.next + 1) % len(rb.lines)
+		rb.n = min(rb.n+1, len(rb.lines))
+	}
+	return len(p), nil
+}
+
+// Lines returns the retained lines, ordered from oldest to newest, without
+// trailing newlines.
+func (rb *RingBuffer) Lines() []string {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	lines := make([]string, 0, rb.n)
+	start := (rb.next - rb.n + len(rb.lines)) % len(rb.lines)
+	for i := range rb.n {
+		lines = append(lines, rb.lines[(start+i)%len(rb.lines)])
+	}
+	return lines
+}
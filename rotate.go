@@ -0,0 +1,179 @@
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// --- [ rotating writer ] -----------------------------------------------------
+
+// RotatingWriter is an output writer to a log file which is rotated when it
+// reaches a given size. RotatingWriter is safe for concurrent use.
+//
+// On rotation, the log file "app.log" is renamed to "app.log.1", the previous
+// "app.log.1" is renamed to "app.log.2", etc., keeping at most a given number
+// of backups.
+type RotatingWriter struct {
+	// mu is a mutex for concurrent access to the log file.
+	mu sync.Mutex
+	// Path of the log file.
+	path string
+	// Size threshold in bytes at which the log file is rotated; or 0 if
+	// unlimited.
+	maxBytes int64
+	// Maximum number of backups of the log file.
+	maxBackups int
+	// Log file.
+	f *os.File
+	// Current size of the log file in bytes.
+	size int64
+}
+
+// NewRotatingWriter returns a new output writer to the given log file, which is
+// rotated when writing to it would exceed maxBytes, keeping at most maxBackups
+// backups of the log file. The log file is appended to if it already exists. A
+// maxBytes of 0 (or below) disables rotation.
+//
+// Example usage:
+//
+//	w, err := clog.NewRotatingWriter("app.log", 10<<20, 3)
+//	if err != nil {
+//		// handle error.
+//	}
+//	defer w.Close()
+//	clog.SetOutput(w)
+func NewRotatingWriter(path string, maxBytes int64, maxBackups int) (*RotatingWriter, error) {
+	rw := &RotatingWriter{
+		path:       path,
+		maxBytes:   max(maxBytes, 0),
+		maxBackups: maxBackups,
+	}
+	if err := rw.open(); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// Write writes p to the log file, first rotating the log file if writing p
+// would exceed the size threshold. Each write is kept within a single log file,
+// so log lines are never split across files.
+//
+// If rotation fails (e.g. a backup cannot be renamed), p is appended to the
+// current log file and the rotation error is returned; rotation is retried on
+// subsequent writes.
+func (rw *RotatingWriter) Write(p []byte) (n int, err error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.f == nil {
+		return 0, fs.ErrClosed
+	}
+	var rotateErr error
+	if rw.maxBytes > 0 && rw.size > 0 && rw.size+int64(len(p)) > rw.maxBytes {
+		rotateErr = rw.rotate()
+		if rw.f == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err = rw.f.Write(p)
+	rw.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, rotateErr
+}
+
+// Close closes the log file.
+func (rw *RotatingWriter) Close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.f == nil {
+		return fs.ErrClosed
+	}
+	err := rw.f.Close()
+	rw.f = nil
+	return err
+}
+
+// reopen closes and reopens the log file by path; e.g. after the log file has
+// been moved by external log rotation.
+func (rw *RotatingWriter) reopen() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.f == nil {
+		return fs.ErrClosed
+	}
+	if err := rw.f.Close(); err != nil {
+		return fmt.Errorf("unable to close log file %q; %w", rw.path, err)
+	}
+	rw.f = nil
+	return rw.open()
+}
+
+// open opens the log file for appending.
+//
+// Note, rw.mu must be held by the caller.
+func (rw *RotatingWriter) open() error {
+	f, err := os.OpenFile(rw.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open log file %q; %w", rw.path, err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to stat log file %q; %w", rw.path, err)
+	}
+	rw.f = f
+	rw.size = fi.Size()
+	return nil
+}
+
+// rotate closes the log file, shifts its backups and reopens an empty log file.
+// On failure, the log file is reopened for appending, so that logging continues
+// without rotation.
+//
+// Note, rw.mu must be held by the caller.
+func (rw *RotatingWriter) rotate() error {
+	err := rw.shiftBackups()
+	if openErr := rw.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// shiftBackups closes the log file and shifts its backups, renaming the log
+// file to the first backup.
+//
+// Note, rw.mu must be held by the caller.
+func (rw *RotatingWriter) shiftBackups() error {
+	err := rw.f.Close()
+	rw.f = nil
+	if err != nil {
+		return fmt.Errorf("unable to close log file %q; %w", rw.path, err)
+	}
+	if rw.maxBackups < 1 {
+		if err := os.Remove(rw.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to remove log file %q; %w", rw.path, err)
+		}
+		return nil
+	}
+	// shift backups; app.log.1 -> app.log.2, etc.
+	for i := rw.maxBackups - 1; i >= 1; i-- {
+		oldPath := rw.backupPath(i)
+		newPath := rw.backupPath(i + 1)
+		if err := os.Rename(oldPath, newPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to rename log file backup %q to %q; %w", oldPath, newPath, err)
+		}
+	}
+	if err := os.Rename(rw.path, rw.backupPath(1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to rename log file %q to %q; %w", rw.path, rw.backupPath(1), err)
+	}
+	return nil
+}
+
+// backupPath returns the path of the i:th backup of the log file.
+func (rw *RotatingWriter) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", rw.path, i)
+}
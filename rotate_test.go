@@ -0,0 +1,98 @@
+package clog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rw, err := NewRotatingWriter(path, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	for _, line := range []string{"1111\n", "2222\n", "3333\n", "4444\n"} {
+		if _, err := rw.Write([]byte(line)); err != nil {
+			t.Fatalf("unable to write %q; %v", line, err)
+		}
+	}
+	golden := map[string]string{
+		path:        "4444\n",
+		path + ".1": "3333\n",
+		path + ".2": "2222\n",
+	}
+	for path, want := range golden {
+		checkFile(t, path, want)
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("expected at most 2 backups, got %q", path+".3")
+	}
+}
+
+func TestRotatingWriterNoLimit(t *testing.T) {
+	for _, maxBytes := range []int64{0, -1} {
+		path := filepath.Join(t.TempDir(), "app.log")
+		rw, err := NewRotatingWriter(path, maxBytes, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{"1111\n", "2222\n"} {
+			if _, err := rw.Write([]byte(line)); err != nil {
+				t.Fatalf("unable to write %q; %v", line, err)
+			}
+		}
+		rw.Close()
+		checkFile(t, path, "1111\n2222\n")
+		if _, err := os.Stat(path + ".1"); err == nil {
+			t.Errorf("maxBytes %d: expected no rotation, got %q", maxBytes, path+".1")
+		}
+	}
+}
+
+func TestRotatingWriterRenameFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// block renaming of the log file to its first backup using a non-empty
+	// directory.
+	if err := os.MkdirAll(filepath.Join(path+".1", "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewRotatingWriter(path, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	if _, err := rw.Write([]byte("1111\n")); err != nil {
+		t.Fatalf("unable to write; %v", err)
+	}
+	if _, err := rw.Write([]byte("2222\n")); err == nil {
+		t.Errorf("expected rotation error, got nil")
+	}
+	// logging continues in the log file once rotation has failed.
+	if _, err := rw.Write([]byte("3333\n")); err == nil {
+		t.Errorf("expected rotation error, got nil")
+	}
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	// rotation is retried on subsequent writes.
+	if _, err := rw.Write([]byte("4444\n")); err != nil {
+		t.Errorf("unable to write; %v", err)
+	}
+	checkFile(t, path, "4444\n")
+	checkFile(t, path+".1", "1111\n2222\n3333\n")
+}
+
+// checkFile checks that the contents of the given file matches want.
+func checkFile(t *testing.T, path, want string) {
+	t.Helper()
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got := string(buf); got != want {
+		t.Errorf("%q: expected contents %q, got %q", path, want, got)
+	}
+}
@@ -0,0 +1,53 @@
+package clog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ sampling ] ------------------------------------------------------------
+
+// sampler samples 1 in n log messages.
+type sampler struct {
+	// Sampling rate; 1 in n log messages is output.
+	n uint64
+	// Number of log messages seen.
+	count atomic.Uint64
+}
+
+var (
+	// samplingMutex is a mutex for concurrent access to samplers.
+	samplingMutex sync.RWMutex
+	// samplers specifies the samplers of log levels.
+	samplers = make(map[Level]*sampler)
+)
+
+// SetSampling sets the sampling rate of the given log level, so that only every
+// n:th log message of the log level is output (starting with the first). A
+// sampling rate of 0 or 1 disables sampling.
+//
+// Sampling is applied after log level filtering, and is cheaper than rate
+// limiting for steady high-frequency output. Messages of log functions which
+// terminate the application or panic (e.g. Fatal and Panic) are always output.
+func SetSampling(level Level, n int) {
+	samplingMutex.Lock()
+	defer samplingMutex.Unlock()
+	if n <= 1 {
+		delete(samplers, level)
+		return
+	}
+	samplers[level] = &sampler{n: uint64(n)}
+}
+
+// sampled reports whether to output the next log message of the given log
+// level, based on its sampling rate.
+func sampled(level Level) bool {
+	samplingMutex.RLock()
+	s, ok := samplers[level]
+	samplingMutex.RUnlock()
+	if !ok {
+		return true
+	}
+	count := s.count.Add(1)
+	return (count-1)%s.n == 0
+}
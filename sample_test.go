@@ -0,0 +1,55 @@
+package clog
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetErrorPrefix(false)
+	defer SetErrorPrefix(true)
+	SetSampling(LevelError, 3)
+	defer SetSampling(LevelError, 0)
+	for i := 0; i < 7; i++ {
+		Errorf("%d", i)
+	}
+	if got, want := buf.String(), "0\n3\n6\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSamplingFatal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	restoreOutput(t, buf)
+	SetErrorPrefix(false)
+	defer SetErrorPrefix(true)
+	SetSampling(LevelError, 2)
+	defer SetSampling(LevelError, 0)
+	codes := catchExit(t)
+	Error("e1")
+	Fatal("x")
+	Log(LevelFatal, "y")
+	if got, want := buf.String(), "e1\nx\ny\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := len(*codes); got != 2 {
+		t.Errorf("expected 2 exits, got %d", got)
+	}
+}
+
+// catchExit records the exit codes of fatal log functions rather than
+// terminating the application, until the test completes.
+func catchExit(t *testing.T) *[]int {
+	t.Helper()
+	var codes []int
+	SetExitFunc(func(code int) {
+		codes = append(codes, code)
+	})
+	t.Cleanup(func() {
+		SetExitFunc(os.Exit)
+	})
+	return &codes
+}
@@ -0,0 +1,109 @@
+package clog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+)
+
+// --- [ slog handler ] --------------------------------------------------------
+
+// slogHandler is a slog.Handler which outputs log records through clog.
+type slogHandler struct {
+	// fields specifies the structured fields of attributes added by WithAttrs.
+	fields Fields
+	// group specifies the key prefix of attributes (e.g. "g1.g2."), as added by
+	// WithGroup.
+	group string
+}
+
+// NewSlogHandler returns a slog.Handler which outputs log records through clog,
+// using the output writers, prefixes and colors of the corresponding clog log
+// levels.
+//
+// The slog log levels map directly onto clog log levels (e.g. slog.LevelWarn
+// corresponds to LevelWarn). Attributes are rendered as structured fields, with
+// keys of grouped attributes qualified by their group names (e.g. "req.id").
+// The source location of log records is used for path level filtering and
+// file:line prefixes.
+//
+// Example usage:
+//
+//	logger := slog.New(clog.NewSlogHandler())
+//	logger.Info("done", "user_id", 42)
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// Enabled reports whether the handler handles log records of the given level;
+// i.e. whether the log level is enabled for any caller. Path level filtering is
+// deferred to Handle, as it depends on the source location of the log record.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return !disabled(Level(level))
+}
+
+// Handle outputs the given log record.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	c := callerFromPC(r.PC)
+	// slog log levels have the same values as clog log levels.
+	level := Level(r.Level)
+	if skip(c, level) {
+		return nil
+	}
+	fields := maps.Clone(h.fields)
+	if fields == nil && r.NumAttrs() > 0 {
+		fields = make(Fields)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addAttr(fields, h.group, attr)
+		return true
+	})
+	write(c, level, r.Message, &Entry{fields: fields})
+	return nil
+}
+
+// WithAttrs returns a new handler whose log records include the given
+// attributes.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(Fields, len(h.fields)+len(attrs))
+	maps.Copy(fields, h.fields)
+	for _, attr := range attrs {
+		addAttr(fields, h.group, attr)
+	}
+	return &slogHandler{fields: fields, group: h.group}
+}
+
+// WithGroup returns a new handler which qualifies the keys of subsequently added
+// attributes by the given group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &slogHandler{fields: h.fields, group: h.group + name + "."}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// addAttr adds the given slog attribute to fields, qualifying its key by the
+// given group prefix. Group attributes are flattened.
+func addAttr(fields Fields, group string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		// ignore empty attributes.
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		subgroup := group
+		if len(attr.Key) > 0 {
+			subgroup += attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			addAttr(fields, subgroup, a)
+		}
+		return
+	}
+	fields[group+attr.Key] = attr.Value.Any()
+}
@@ -0,0 +1,100 @@
+package clog
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// --- [ subscribers ] ---------------------------------------------------------
+
+// Event is a log message delivered to subscribers.
+type Event struct {
+	// Log level of the log message.
+	Level Level
+	// Package name of the caller (e.g. "pkg").
+	Pkg string
+	// Function name of the caller (e.g. "(*Server).Handle").
+	Func string
+	// Source file name of the caller.
+	File string
+	// Source line number of the caller.
+	Line int
+	// Time of the log message.
+	Time time.Time
+	// Log message.
+	Msg string
+	// Structured fields of the log message; or nil if none.
+	Fields Fields
+}
+
+var (
+	// subscribeMutex is a readers/writer mutex for concurrent access to
+	// subscribers.
+	subscribeMutex sync.RWMutex
+	// subscribers specifies the event channels of subscribers.
+	subscribers []chan Event
+)
+
+// Subscribe subscribes to log messages, and returns a channel on which events
+// are delivered for every log message which is not filtered by log levels,
+// after the log message has been output. The returned function unsubscribes
+// and closes the channel.
+//
+// The channel is buffered with the given capacity. Events are dropped for a
+// subscriber whose channel is full, rather than blocking the log call.
+//
+// Example usage:
+//
+//	events, unsubscribe := clog.Subscribe(100)
+//	defer unsubscribe()
+//	go func() {
+//		for e := range events {
+//			conn.WriteJSON(e)
+//		}
+//	}()
+func Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	subscribeMutex.Lock()
+	subscribers = append(subscribers, ch)
+	subscribeMutex.Unlock()
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			subscribeMutex.Lock()
+			defer subscribeMutex.Unlock()
+			subscribers = slices.DeleteFunc(subscribers, func(sub chan Event) bool {
+				return sub == ch
+			})
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// notifySubscribers delivers the given log message to subscribers.
+func notifySubscribers(r *record) {
+	subscribeMutex.RLock()
+	defer subscribeMutex.RUnlock()
+	if len(subscribers) == 0 {
+		return
+	}
+	e := Event{
+		Level:  r.level,
+		Pkg:    getPkgName(r.caller.name),
+		Func:   getFuncName(r.caller.name),
+		File:   r.caller.file,
+		Line:   r.caller.line,
+		Time:   r.time,
+		Msg:    r.msg,
+		Fields: maps.Clone(r.fields),
+	}
+	for _, ch := range subscribers {
+		select {
+		case ch <- e:
+		default:
+			// drop event if subscriber is not keeping up.
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows && !plan9
+
+package clog
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// --- [ syslog ] --------------------------------------------------------------
+
+// UseSyslog sets the output writers of all log levels to the system log
+// service, using the given tag and the syslog priority corresponding to each
+// log level: LevelDebug to LOG_DEBUG, LevelInfo to LOG_INFO, LevelWarn to
+// LOG_WARNING and LevelError to LOG_ERR.
+//
+// UseSyslog returns an error on platforms without syslog support (Windows and
+// Plan 9).
+func UseSyslog(tag string) error {
+	levels := []Level{LevelDebug, LevelInfo, LevelWarn, LevelError}
+	priorities := []syslog.Priority{syslog.LOG_DEBUG, syslog.LOG_INFO, syslog.LOG_WARNING, syslog.LOG_ERR}
+	var writers []*syslog.Writer
+	for _, priority := range priorities {
+		w, err := syslog.New(priority|syslog.LOG_USER, tag)
+		if err != nil {
+			for _, w := range writers {
+				w.Close()
+			}
+			return fmt.Errorf("unable to connect to system log service; %w", err)
+		}
+		writers = append(writers, w)
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	for i, level := range levels {
+		setLevelOutput(level, writers[i])
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build windows || plan9
+
+package clog
+
+import (
+	"errors"
+)
+
+// --- [ syslog ] --------------------------------------------------------------
+
+// UseSyslog sets the output writers of all log levels to the system log
+// service. Syslog is not supported on this platform, so an error is always
+// returned.
+func UseSyslog(tag string) error {
+	return errors.New("unable to connect to system log service; syslog not supported on this platform")
+}
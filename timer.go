@@ -0,0 +1,62 @@
+package clog
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- [ timing ] --------------------------------------------------------------
+
+// Since outputs the given info message followed by the duration elapsed since
+// start (e.g. "loaded config 12.345ms").
+//
+// Example usage:
+//
+//	start := time.Now()
+//	load()
+//	clog.Since(start, "loaded config")
+func Since(start time.Time, args ...any) {
+	if disabled(LevelInfo) {
+		return
+	}
+	c := getCaller()
+	if skip(c, LevelInfo) {
+		return
+	}
+	write(c, LevelInfo, durationMsg(fmt.Sprint(args...), now().Sub(start)), nil)
+}
+
+// Timer starts a timer, and returns a function which outputs an info message
+// with the given label and the elapsed duration when invoked (e.g. "load took
+// 12.345ms"). The message is attributed to the caller of Timer.
+//
+// Example usage:
+//
+//	defer clog.Timer("load")()
+func Timer(label string) func() {
+	start := now()
+	c := getCaller()
+	return func() {
+		if disabled(LevelInfo) || skip(c, LevelInfo) {
+			return
+		}
+		write(c, LevelInfo, durationMsg(label+" took", now().Sub(start)), nil)
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// durationMsg returns the given message followed by the given duration, rounded
+// to a precision suitable for display.
+func durationMsg(msg string, d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		d = d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(time.Microsecond)
+	}
+	if len(msg) == 0 {
+		return d.String()
+	}
+	return msg + " " + d.String()
+}
@@ -0,0 +1,74 @@
+package clog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ trace IDs ] -----------------------------------------------------------
+
+// Trace IDs are tracked per goroutine, keyed by the goroutine ID of the caller
+// (as parsed from its stack trace, see goroutineID), as Go provides no
+// goroutine-local storage. Trace IDs are therefore not inherited by goroutines
+// started within a request scope; call SetTraceID in each goroutine which
+// should log the trace ID. The trace ID of a goroutine which exits without
+// calling ClearTraceID is never released; always pair SetTraceID with
+// ClearTraceID (e.g. using defer).
+
+var (
+	// traceMutex is a mutex for concurrent access to traceIDs.
+	traceMutex sync.Mutex
+	// traceIDs specifies the trace IDs of goroutines, keyed by goroutine ID.
+	traceIDs = make(map[uint64]string)
+	// traceCount specifies the number of goroutines with a trace ID; used to
+	// skip goroutine ID lookups when no trace ID is set.
+	traceCount atomic.Int64
+)
+
+// SetTraceID sets the trace ID of the calling goroutine, which is included in
+// the prefix of its log messages (e.g. "[trace=abc123]"), and as the "trace"
+// field of log messages output using a formatter.
+//
+// Example usage:
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		clog.SetTraceID(r.Header.Get("X-Trace-Id"))
+//		defer clog.ClearTraceID()
+//		...
+//	}
+func SetTraceID(id string) {
+	gid := goroutineID()
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	traceIDs[gid] = id
+	traceCount.Store(int64(len(traceIDs)))
+}
+
+// ClearTraceID clears the trace ID of the calling goroutine.
+func ClearTraceID() {
+	gid := goroutineID()
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	delete(traceIDs, gid)
+	traceCount.Store(int64(len(traceIDs)))
+}
+
+// ### [ Helper functions ] ####################################################
+
+// traceID returns the trace ID of the calling goroutine, or an empty string if
+// not set.
+func traceID() string {
+	if traceCount.Load() == 0 {
+		return ""
+	}
+	gid := goroutineID()
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	return traceIDs[gid]
+}
+
+// getTraceID returns the trace ID prefix of the given trace ID, using the given
+// terminal color.
+func getTraceID(id string, colorFunc func(string) string) string {
+	return colorFunc("[trace="+id+"]") + " "
+}
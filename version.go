@@ -0,0 +1,85 @@
+package clog
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// --- [ module version ] ------------------------------------------------------
+
+// useModuleVersion specifies whether to include the module version of the
+// caller in prefixes.
+var useModuleVersion bool
+
+// SetShowModuleVersion sets whether to include the version of the module
+// containing the package of the caller in prefixes (e.g. "pkg@v1.2.3:"), and as
+// the "version" field of log messages output using a formatter; default false.
+//
+// Module versions are read from the build information embedded in the binary
+// (see debug.ReadBuildInfo); no version is included if build information is
+// not available, or the version of the main module is unknown (e.g. "go run").
+func SetShowModuleVersion(enable bool) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	useModuleVersion = enable
+}
+
+var (
+	// buildInfo returns the build information embedded in the binary; or nil if
+	// not available.
+	buildInfo = sync.OnceValue(func() *debug.BuildInfo {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return nil
+		}
+		return info
+	})
+
+	// moduleVersions caches the module versions of package paths.
+	moduleVersions sync.Map // map[string]string
+)
+
+// ### [ Helper functions ] ####################################################
+
+// moduleVersion returns the version of the module containing the given package
+// path; or an empty string if unknown.
+func moduleVersion(pkgPath string) string {
+	if v, ok := moduleVersions.Load(pkgPath); ok {
+		return v.(string)
+	}
+	version := lookupModuleVersion(pkgPath)
+	moduleVersions.Store(pkgPath, version)
+	return version
+}
+
+// lookupModuleVersion returns the version of the module containing the given
+// package path, as specified by the build information; or an empty string if
+// unknown.
+func lookupModuleVersion(pkgPath string) string {
+	info := buildInfo()
+	if info == nil {
+		return ""
+	}
+	// find the module with the longest module path containing the package.
+	var match *debug.Module
+	mods := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, mod := range mods {
+		if pkgPath != mod.Path && !strings.HasPrefix(pkgPath, mod.Path+"/") {
+			continue
+		}
+		if match == nil || len(mod.Path) > len(match.Path) {
+			match = mod
+		}
+	}
+	if match == nil {
+		return ""
+	}
+	if match.Replace != nil {
+		match = match.Replace
+	}
+	if match.Version == "(devel)" {
+		return ""
+	}
+	return match.Version
+}
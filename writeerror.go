@@ -0,0 +1,97 @@
+package clog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// --- [ write errors ] --------------------------------------------------------
+
+var (
+	// writeErrorMutex is a mutex for concurrent access to writeErrorHandler.
+	writeErrorMutex sync.Mutex
+	// writeErrorHandler specifies the function invoked with errors of output
+	// writers; or nil to use the default handler.
+	writeErrorHandler func(err error)
+	// writeErrorOnce ensures that the default handler reports write errors at
+	// most once.
+	writeErrorOnce sync.Once
+
+	// pendingWriteErrorsMutex is a mutex for concurrent access to
+	// pendingWriteErrors.
+	pendingWriteErrorsMutex sync.Mutex
+	// pendingWriteErrors specifies errors of output writers pending report
+	// to the write error handler.
+	pendingWriteErrors []error
+	// hasWriteErrors specifies whether there are errors pending report; used
+	// to skip locking outputMutex when there are none.
+	hasWriteErrors atomic.Bool
+)
+
+// SetWriteErrorHandler sets the function invoked with errors returned by output
+// writers (e.g. a broken pipe or a full disk), which would otherwise cause log
+// messages to be silently dropped. By default, a notice of the first write
+// error is written to standard error. A nil function restores the default
+// handler.
+//
+// The handler is invoked without holding any locks of clog, and may thus call
+// log functions of clog (e.g. after reopening a log file). In asynchronous mode
+// (see SetAsync), write errors are reported by the next log call or call to
+// Flush, rather than by the background goroutine.
+//
+// Example usage:
+//
+//	clog.SetWriteErrorHandler(func(err error) {
+//		writeErrors.Inc()
+//	})
+func SetWriteErrorHandler(fn func(err error)) {
+	writeErrorMutex.Lock()
+	defer writeErrorMutex.Unlock()
+	writeErrorHandler = fn
+}
+
+// handleWriteError invokes the write error handler with the given error of an
+// output writer.
+//
+// Note, no locks of clog may be held by the caller.
+func handleWriteError(err error) {
+	writeErrorMutex.Lock()
+	fn := writeErrorHandler
+	writeErrorMutex.Unlock()
+	if fn != nil {
+		fn(err)
+		return
+	}
+	writeErrorOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "clog: unable to write log message; %v (further write errors are not reported)\n", err)
+	})
+}
+
+// recordWriteError records the given error of an output writer, pending report
+// to the write error handler (see reportWriteErrors).
+func recordWriteError(err error) {
+	pendingWriteErrorsMutex.Lock()
+	defer pendingWriteErrorsMutex.Unlock()
+	pendingWriteErrors = append(pendingWriteErrors, err)
+	hasWriteErrors.Store(true)
+}
+
+// reportWriteErrors invokes the write error handler with the pending errors of
+// output writers.
+//
+// Note, no locks of clog may be held by the caller.
+func reportWriteErrors() {
+	if !hasWriteErrors.Load() {
+		return
+	}
+	pendingWriteErrorsMutex.Lock()
+	errs := pendingWriteErrors
+	pendingWriteErrors = nil
+	hasWriteErrors.Store(false)
+	pendingWriteErrorsMutex.Unlock()
+	for _, err := range errs {
+		handleWriteError(err)
+	}
+}
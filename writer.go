@@ -0,0 +1,117 @@
+package clog
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// --- [ level writer ] --------------------------------------------------------
+
+// levelWriter is an output writer which outputs each write as a log message of
+// a given log level.
+type levelWriter struct {
+	// Log level of messages.
+	level Level
+}
+
+// Writer returns an output writer which outputs each write as a log message of
+// the given log level, with the trailing newline trimmed. Log messages of
+// LevelError do not terminate the application.
+//
+// Writer may be used to route the output of the standard log package through
+// clog:
+//
+//	log.SetFlags(0)
+//	log.SetOutput(clog.Writer(clog.LevelInfo))
+//
+// Note, the caller of the writer is the standard log package (or whichever
+// package writes to the writer), not the original call site. Path levels,
+// package name prefixes and file:line prefixes are therefore resolved for the
+// writing package (e.g. "log"); use SetInfoPrefix (and siblings) to disable
+// prefixes if they are not meaningful.
+func Writer(level Level) io.Writer {
+	return levelWriter{level: level}
+}
+
+// Write outputs p as a log message.
+func (lw levelWriter) Write(p []byte) (n int, err error) {
+	if disabled(lw.level) {
+		return len(p), nil
+	}
+	c := getCaller()
+	if skip(c, lw.level) {
+		return len(p), nil
+	}
+	msg := strings.TrimSuffix(string(p), "\n")
+	write(c, lw.level, msg, nil)
+	return len(p), nil
+}
+
+// --- [ capture ] -------------------------------------------------------------
+
+// CaptureOutput runs fn with the output writers of all log levels redirected to
+// an internal buffer, and returns the captured output. The previous output
+// writers are restored once fn returns, even if fn panics.
+//
+// CaptureOutput is intended for tests asserting on log output. Note, output
+// from other goroutines logging while fn runs is captured as well. The
+// formatter is left unchanged, even if automatic formatter selection is enabled
+// (see SetAutoFormat).
+func CaptureOutput(fn func()) string {
+	buf := &bytes.Buffer{}
+	outputMutex.Lock()
+	prevDebugOutputs, prevInfoOutputs := debugOutputs, infoOutputs
+	prevWarnOutputs, prevErrorOutputs := warnOutputs, errorOutputs
+	sinks := []sink{newSink(buf)}
+	debugOutputs, infoOutputs = sinks, sinks
+	warnOutputs, errorOutputs = sinks, sinks
+	outputMutex.Unlock()
+	defer func() {
+		Flush()
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
+		debugOutputs, infoOutputs = prevDebugOutputs, prevInfoOutputs
+		warnOutputs, errorOutputs = prevWarnOutputs, prevErrorOutputs
+	}()
+	fn()
+	Flush()
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return buf.String()
+}
+
+// --- [ strip color ] ---------------------------------------------------------
+
+// sgrRegexp matches ANSI SGR (Select Graphic Rendition) escape sequences, as
+// used for terminal colors.
+var sgrRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// StripColor returns s with ANSI color escape sequences removed.
+func StripColor(s string) string {
+	return sgrRegexp.ReplaceAllString(s, "")
+}
+
+// stripWriter is an output writer which removes ANSI color escape sequences
+// before writing to an underlying writer.
+type stripWriter struct {
+	// Underlying writer.
+	w io.Writer
+}
+
+// NewStripWriter returns an output writer which removes ANSI color escape
+// sequences from each write before writing to w. Escape sequences split across
+// writes are not removed.
+func NewStripWriter(w io.Writer) io.Writer {
+	return stripWriter{w: w}
+}
+
+// Write writes p to the underlying writer, with ANSI color escape sequences
+// removed.
+func (sw stripWriter) Write(p []byte) (n int, err error) {
+	if _, err := sw.w.Write(sgrRegexp.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}